@@ -43,3 +43,163 @@ func TestTmuxWrapper_Apply(t *testing.T)  {
 	t.Run("TmuxWrapper", suite.testTmuxWrapperApply)
 
 }
+
+type LayoutSuite struct {
+}
+
+func TestPrepareLayout(t *testing.T) {
+	suite := LayoutSuite{}
+	readTestConfig("prepare_layout_testcases")
+	t.Run("TestPrepareLayout", suite.testPrepareLayout)
+}
+
+func TestParseLayout(t *testing.T) {
+	suite := LayoutSuite{}
+	readTestConfig("prepare_layout_testcases")
+	t.Run("TestParseLayout", suite.testParseLayout)
+}
+
+func TestTmuxWrapper_Freeze(t *testing.T) {
+	suite := TmuxWrapperTestSuite{}
+	readTestConfig("tmux_wrapper_freeze_test_cases")
+	t.Run("TmuxWrapper", suite.testTmuxWrapperFreeze)
+}
+
+func TestTmuxWrapper_Update(t *testing.T) {
+	suite := TmuxWrapperTestSuite{}
+	readTestConfig("tmux_wrapper_update_test_cases")
+	t.Run("TmuxWrapper", suite.testTmuxWrapperApply)
+}
+
+func TestTmuxWrapper_Replace(t *testing.T) {
+	suite := TmuxWrapperTestSuite{}
+	readTestConfig("tmux_wrapper_replace_test_cases")
+	t.Run("TmuxWrapper", suite.testTmuxWrapperApply)
+}
+
+func TestTmuxWrapper_Attach(t *testing.T) {
+	suite := TmuxWrapperTestSuite{}
+	readTestConfig("tmux_wrapper_attach_test_cases")
+	t.Run("TmuxWrapper", suite.testTmuxWrapperApply)
+}
+
+func TestTmuxWrapper_Here(t *testing.T) {
+	suite := TmuxWrapperTestSuite{}
+	readTestConfig("tmux_wrapper_here_test_cases")
+	t.Run("TmuxWrapper", suite.testTmuxWrapperApply)
+}
+
+func TestTmuxWrapper_Split(t *testing.T) {
+	suite := TmuxWrapperTestSuite{}
+	readTestConfig("tmux_wrapper_split_test_cases")
+	t.Run("TmuxWrapper", suite.testTmuxWrapperApply)
+}
+
+func TestTmuxWrapper_WorkingDirectory(t *testing.T) {
+	suite := TmuxWrapperTestSuite{}
+	readTestConfig("tmux_wrapper_workdir_test_cases")
+	t.Run("TmuxWrapper", suite.testTmuxWrapperApply)
+}
+
+func TestTmuxWrapper_SendKeys(t *testing.T) {
+	suite := TmuxWrapperTestSuite{}
+	readTestConfig("tmux_wrapper_send_keys_test_cases")
+	t.Run("TmuxWrapper", suite.testTmuxWrapperApply)
+}
+
+func TestTmuxWrapper_Script(t *testing.T) {
+	suite := TmuxWrapperTestSuite{}
+	readTestConfig("tmux_wrapper_script_test_cases")
+	t.Run("TmuxWrapper", suite.testTmuxWrapperApply)
+}
+
+func TestTmuxWrapper_Environment(t *testing.T) {
+	suite := TmuxWrapperTestSuite{}
+	readTestConfig("tmux_wrapper_env_test_cases")
+	t.Run("TmuxWrapper", suite.testTmuxWrapperApply)
+}
+
+type EnvFileSuite struct {
+}
+
+func TestParseEnvFile(t *testing.T) {
+	suite := EnvFileSuite{}
+	readTestConfig("parse_env_file_testcases")
+	t.Run("TestParseEnvFile", suite.testParseEnvFile)
+}
+
+func TestTmuxWrapper_Interpolate(t *testing.T) {
+	suite := TmuxWrapperTestSuite{}
+	readTestConfig("tmux_wrapper_vars_test_cases")
+	t.Run("TmuxWrapper", suite.testTmuxWrapperApply)
+}
+
+type InputSuite struct {
+}
+
+func TestResolveInputs(t *testing.T) {
+	suite := InputSuite{}
+	readTestConfig("resolve_inputs_testcases")
+	t.Run("TestResolveInputs", suite.testResolveInputs)
+	t.Run("TestTerminalPrompter", suite.testTerminalPrompter)
+}
+
+type ConfigFileSuite struct {
+}
+
+func TestLoadConfig(t *testing.T) {
+	suite := ConfigFileSuite{}
+	readTestConfig("load_config_testcases")
+	t.Run("TestLoadConfig", suite.testLoadConfig)
+}
+
+type SessionSuite struct {
+}
+
+func TestSelectSessions(t *testing.T) {
+	suite := SessionSuite{}
+	readTestConfig("select_sessions_testcases")
+	t.Run("TestSelectSessions", suite.testSelectSessions)
+}
+
+type ProfileSuite struct {
+}
+
+func TestApplyProfiles(t *testing.T) {
+	suite := ProfileSuite{}
+	readTestConfig("apply_profiles_testcases")
+	t.Run("TestApplyProfiles", suite.testApplyProfiles)
+}
+
+type ConditionSuite struct {
+}
+
+func TestSkipWindows(t *testing.T) {
+	suite := ConditionSuite{}
+	readTestConfig("skip_windows_testcases")
+	t.Run("TestSkipWindows", suite.testSkipWindows)
+}
+
+func TestTmuxWrapper_Wait(t *testing.T) {
+	suite := TmuxWrapperTestSuite{}
+	readTestConfig("tmux_wrapper_wait_test_cases")
+	t.Run("TmuxWrapper", suite.testTmuxWrapperApply)
+}
+
+func TestTmuxWrapper_DependsOn(t *testing.T) {
+	suite := TmuxWrapperTestSuite{}
+	readTestConfig("tmux_wrapper_depends_test_cases")
+	t.Run("TmuxWrapper", suite.testTmuxWrapperApply)
+}
+
+func TestTmuxWrapper_Hooks(t *testing.T) {
+	suite := TmuxWrapperTestSuite{}
+	readTestConfig("tmux_wrapper_hooks_test_cases")
+	t.Run("TmuxWrapper", suite.testTmuxWrapperApply)
+}
+
+func TestTmuxWrapper_Down(t *testing.T) {
+	suite := TmuxWrapperTestSuite{}
+	readTestConfig("tmux_wrapper_down_test_cases")
+	t.Run("TmuxWrapper", suite.testTmuxWrapperApply)
+}
@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"sync"
+
+	"github.com/pallavJha/chaakoo"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+)
+
+var (
+	downCmd = &cobra.Command{
+		Use:   "down [session...]",
+		Short: "stops the sessions of the config and kills them",
+		Long: `stops the session of the config, or the sessions whose names are passed, and kills them
+The stop sequence of every pane, or C-c, is sent to it and the session is killed after the commands stop or after
+--stop-timeout, 10s by default. The hooks of the config are run once, before and after the sessions.
+The inputs are not prompted, they take the values of the --set flags or their default values.
+$ chaakoo down --stop-timeout 30s`,
+		Run: func(cmd *cobra.Command, args []string) {
+			readConfig()
+			var config chaakoo.Config
+			loadConfig(&config)
+			// the inputs are not prompted, they take the values of the --set flags or their default values
+			interpolateConfig(&config, false)
+			sessions, err := config.SelectSessions(args)
+			if err != nil {
+				log.Fatal().Err(err).Msg("cannot select the sessions")
+			}
+			// the panes skipped by their when condition were not started, so they are not stopped
+			for _, session := range sessions {
+				if err = session.Parse(); err != nil {
+					log.Fatal().Err(err).Msgf("cannot parse the session, %s", session.SessionName)
+				}
+			}
+			applyFlags(&config)
+			runConfigHooks(&config, chaakoo.BeforeStopHook)
+
+			var errs = make([]error, len(sessions))
+			var wg sync.WaitGroup
+			for i, session := range sessions {
+				wg.Add(1)
+				go func(i int, session *chaakoo.Config) {
+					defer wg.Done()
+					applyFlags(session)
+					errs[i] = chaakoo.NewTmuxWrapper(session, nil).Down()
+				}(i, session)
+			}
+			wg.Wait()
+
+			var failed int
+			for i, session := range sessions {
+				if errs[i] != nil {
+					log.Error().Err(errs[i]).Msgf("cannot stop the session, %s", session.SessionName)
+					failed++
+					continue
+				}
+				log.Info().Msgf("session, %s, is stopped", session.SessionName)
+			}
+			if failed > 0 {
+				log.Fatal().Msgf("%d of %d sessions cannot be stopped", failed, len(sessions))
+			}
+			runConfigHooks(&config, chaakoo.AfterStopHook)
+		},
+	}
+)
+
+func init() {
+	rootCmd.AddCommand(downCmd)
+}
@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+
+	"github.com/pallavJha/chaakoo"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+)
+
+var (
+	outputFile string
+
+	freezeCmd = &cobra.Command{
+		Use:   "freeze <session>",
+		Short: "converts a running TMUX session into a config",
+		Long: `converts the windows and panes of a running TMUX session into a config
+The panes are named after their titles or current commands, their current paths are kept as the workdir and their
+current commands, other than the shells, are kept as the command`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			wrapper := chaakoo.NewTmuxWrapper(&chaakoo.Config{SessionName: args[0]}, nil)
+			config, err := wrapper.Freeze(tolerance / 100)
+			if err != nil {
+				log.Fatal().Err(err).Msg("cannot freeze the session")
+			}
+			content, err := yaml.Marshal(config)
+			if err != nil {
+				log.Fatal().Err(err).Msg("cannot convert the config into yaml")
+			}
+			if len(outputFile) == 0 {
+				fmt.Print(string(content))
+				return
+			}
+			if err = ioutil.WriteFile(outputFile, content, 0644); err != nil {
+				log.Fatal().Err(err).Msgf("cannot write the config to %s", outputFile)
+			}
+			log.Info().Msgf("config for session, %s, is written to %s", args[0], outputFile)
+		},
+	}
+)
+
+func init() {
+	freezeCmd.Flags().StringVarP(&outputFile, "output", "o", "", "file to write the config to, the config is printed if not provided")
+	freezeCmd.Flags().Float64VarP(&tolerance, "tolerance", "t", 2,
+		"allowed distance of the pane edges from their actual position, in percentage of the window size")
+	rootCmd.AddCommand(freezeCmd)
+}
@@ -0,0 +1,43 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/pallavJha/chaakoo"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+)
+
+var (
+	tolerance float64
+
+	importLayoutCmd = &cobra.Command{
+		Use:   "import-layout <layout>",
+		Short: "converts a TMUX layout string into a grid",
+		Long: `converts a TMUX layout string into a grid that can be used in the config
+The layout of the current window can be found by executing -> $ tmux display -p '#{window_layout}'`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			layout, err := chaakoo.ParseLayout(args[0])
+			if err != nil {
+				log.Fatal().Err(err).Msg("cannot parse the layout")
+			}
+			grid, err := layout.AsGrid(tolerance / 100)
+			if err != nil {
+				log.Fatal().Err(err).Msg("cannot convert the layout into a grid")
+			}
+			// the grid must be usable in a window
+			window := &chaakoo.Window{Name: "import", Grid: chaakoo.FormatGrid(grid)}
+			if err = window.Parse(); err != nil {
+				log.Fatal().Err(err).Str("grid", window.Grid).Msg("the layout cannot be represented by chaakoo")
+			}
+			fmt.Print(window.Grid)
+		},
+	}
+)
+
+func init() {
+	importLayoutCmd.Flags().Float64VarP(&tolerance, "tolerance", "t", 2,
+		"allowed distance of the pane edges from their actual position, in percentage of the window size")
+	rootCmd.AddCommand(importLayoutCmd)
+}
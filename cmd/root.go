@@ -1,14 +1,17 @@
 package cmd
 
 import (
+	"fmt"
 	"github.com/pallavJha/chaakoo"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
+	"golang.org/x/term"
 )
 
 var (
@@ -19,6 +22,17 @@ var (
 	showVersion bool
 	version     string
 	exitOnError bool
+	update      bool
+	keepOnError bool
+	replace     bool
+	attach      bool
+	here        bool
+	currentWin  bool
+	changeDir   bool
+	stopTimeout time.Duration
+	setValues   []string
+	overrideCfg string
+	profiles    []string
 	height      int
 	width       int
 
@@ -30,40 +44,55 @@ var (
 				log.Info().Msgf("version: %s", version)
 				return
 			}
-			var config chaakoo.Config
-			if err := viper.Unmarshal(&config); err != nil {
-				// TODO: add helpful example for a config
-				log.Fatal().Err(err).Msg("cannot unmarshal the config")
+			readConfig()
+			if replace && update {
+				log.Fatal().Msg("--replace and --update cannot be used together")
+			}
+			if here && (replace || update) {
+				log.Fatal().Msg("--here cannot be used with --replace or --update")
+			}
+			if currentWin && !here {
+				log.Fatal().Msg("--current-window can only be used with --here")
 			}
-			if err := config.Validate(); err != nil {
-				log.Fatal().Err(err).Msg("validation errors found in the config")
+			var config chaakoo.Config
+			loadConfig(&config)
+			interpolateConfig(&config, true)
+			if len(config.Sessions) > 0 {
+				log.Fatal().Msg("the config has sessions, they can be created with chaakoo up")
 			}
-			if err := config.Parse(); err != nil {
-				log.Fatal().Err(err).Msg("cannot parse the grid for a window")
+			if err := prepareConfig(&config); err != nil {
+				log.Fatal().Err(err).Msg("invalid config")
 			}
-			config.DryRun = dryRun
-			config.ExitOnError = exitOnError
+			applyFlags(&config)
 
-			var err error
 			var dimension *chaakoo.Dimension
-			if height == 0 || width == 0 {
-				log.Debug().Msg("finding the dimensions")
-				dimUsingTerm := &chaakoo.DimensionUsingTerm{}
-				dimension, err = dimUsingTerm.Dimension()
-				if err != nil {
-					log.Fatal().Err(err).Msg("cannot find the terminal dimensions")
-				}
-				log.Debug().Int("width", dimension.Width).Int("height", dimension.Height).Msg("found dimensions")
-			} else {
-				dimension = chaakoo.NewDimension(width, height)
+			// with --here, the size of the current window is used
+			if !here {
+				dimension = findDimension()
 			}
 
 			wrapper := chaakoo.NewTmuxWrapper(&config, dimension)
-			err = wrapper.Apply()
+			err := wrapper.Apply()
 			if err != nil {
 				log.Fatal().Err(err).Msg("error while applying the config")
 			}
-			log.Info().Msg("session created successfully, it can be attached by executing:")
+			if attach {
+				if err = wrapper.Attach(); err != nil {
+					log.Fatal().Err(err).Msg("error while attaching the session")
+				}
+				return
+			}
+			if here {
+				log.Info().Msgf("windows created successfully in the current session, %s", config.SessionName)
+				return
+			}
+			if update {
+				log.Info().Msg("session updated successfully, it can be attached by executing:")
+			} else if replace {
+				log.Info().Msg("session replaced successfully, it can be attached by executing:")
+			} else {
+				log.Info().Msg("session created successfully, it can be attached by executing:")
+			}
 			log.Info().Msgf("tmux a -t %s", config.SessionName)
 		},
 	}
@@ -76,10 +105,21 @@ func Execute() error {
 
 func init() {
 	rootCmd.PersistentFlags().StringVarP(&cfgFile, "config", "c", "", "config file (default is ./chaakoo.yaml)")
+	rootCmd.PersistentFlags().StringVar(&overrideCfg, "override", "", "config file that is merged into the config (default is chaakoo.local.yaml next to the config file, if it is present)")
 	rootCmd.PersistentFlags().BoolVarP(&verboseLog, "verbose", "v", false, "enable verbose logging")
 	rootCmd.PersistentFlags().BoolVarP(&showVersion, "version", "V", false, "print the version")
 	rootCmd.PersistentFlags().BoolVarP(&dryRun, "dry-run", "d", false, "if true then commands will only be shown and not executed")
 	rootCmd.PersistentFlags().BoolVarP(&exitOnError, "exit-on-error", "e", false, "if true then chaakoo will exit after it encounters the first error during command execution")
+	rootCmd.PersistentFlags().BoolVarP(&update, "update", "u", false, "if true then an already present session is updated with the windows and panes that are missing from it")
+	rootCmd.PersistentFlags().BoolVarP(&keepOnError, "keep-on-error", "k", false, "if true then the session, windows and panes created before an error are not killed")
+	rootCmd.PersistentFlags().BoolVarP(&replace, "replace", "R", false, "if true then an already present session with the same name is killed and created again")
+	rootCmd.PersistentFlags().DurationVar(&stopTimeout, "stop-timeout", 0, "with --replace or down, time given to the commands to stop after their stop sequences, or C-c, are sent to the panes, with --replace if 0 then the session is killed directly and down waits for 10s")
+	rootCmd.PersistentFlags().BoolVarP(&attach, "attach", "a", false, "if true then the session is attached after it is created, or if it is already present, and inside TMUX the client is switched to it")
+	rootCmd.PersistentFlags().BoolVarP(&here, "here", "H", false, "if true then the windows are created in the current TMUX session instead of a new session")
+	rootCmd.PersistentFlags().BoolVar(&currentWin, "current-window", false, "with --here, the grid of the first window is applied on the current window by splitting the current pane")
+	rootCmd.PersistentFlags().BoolVar(&changeDir, "cd", false, "if true then the panes are created in the current directory and then cd is sent to them instead of creating them in their workdirs")
+	rootCmd.PersistentFlags().StringArrayVarP(&profiles, "profile", "p", nil, "profile of the config that is applied, can be repeated to apply the profiles in order")
+	rootCmd.PersistentFlags().StringArrayVar(&setValues, "set", nil, "value of a variable of the config as name=value, it overrides the environment variables and the vars of the config, can be repeated")
 	rootCmd.PersistentFlags().IntVarP(&height, "height", "r", 0, "terminal dimension for rows or height, if 0 then rows and cols will be found internally")
 	rootCmd.PersistentFlags().IntVarP(&width, "width", "w", 0, "terminal dimension for cols or width")
 
@@ -88,9 +128,6 @@ func init() {
 
 func initConfig() {
 	reconfigureLogger()
-	if !showVersion {
-		readConfig()
-	}
 }
 
 func readConfig() {
@@ -114,6 +151,106 @@ func readConfig() {
 	log.Debug().Msgf("using config file: %s", viper.ConfigFileUsed())
 }
 
+// loadConfig loads the config file that is found by readConfig, along with the files that it extends and includes,
+// merges the override file or the local config into it and then applies the selected profiles.
+// The file is decoded with its yaml tags, see chaakoo.LoadConfig, as viper lowercases the keys of the maps at the top
+// of the config, like the names of the environment variables of the session.
+func loadConfig(config *chaakoo.Config) {
+	var overridePaths []string
+	if len(overrideCfg) > 0 {
+		overridePaths = append(overridePaths, overrideCfg)
+	} else if localConfig := chaakoo.LocalConfigPath(viper.ConfigFileUsed()); fileExists(localConfig) {
+		log.Debug().Msgf("using local config file: %s", localConfig)
+		overridePaths = append(overridePaths, localConfig)
+	}
+	loadedConfig, err := chaakoo.LoadConfig(viper.ConfigFileUsed(), overridePaths...)
+	if err != nil {
+		// TODO: add helpful example for a config
+		log.Fatal().Err(err).Msg("cannot load the config")
+	}
+	for _, profile := range profiles {
+		if err = loadedConfig.ApplyProfile(profile); err != nil {
+			log.Fatal().Err(err).Msg("cannot apply the profile")
+		}
+	}
+	*config = *loadedConfig
+}
+
+// interpolateConfig replaces the variables of the config with the values of the --set flags, the inputs, the
+// environment variables and the vars of the config. The inputs are prompted if prompt is true and chaakoo is running
+// in a terminal, otherwise they take their default values.
+func interpolateConfig(config *chaakoo.Config, prompt bool) {
+	var values = make(map[string]string)
+	for _, setValue := range setValues {
+		separatorIndex := strings.Index(setValue, "=")
+		if separatorIndex < 1 {
+			log.Fatal().Msgf("invalid --set, %s, expected name=value", setValue)
+		}
+		values[setValue[:separatorIndex]] = setValue[separatorIndex+1:]
+	}
+	var prompter chaakoo.Prompter
+	if prompt && term.IsTerminal(int(os.Stdin.Fd())) {
+		prompter = chaakoo.NewTerminalPrompter()
+	}
+	if err := config.ResolveInputs(values, prompter); err != nil {
+		log.Fatal().Err(err).Msg("cannot resolve the inputs")
+	}
+	if err := config.Interpolate(values); err != nil {
+		log.Fatal().Err(err).Msg("cannot interpolate the config")
+	}
+}
+
+// prepareConfig validates and parses the config, and then resolves its working directories and env files
+func prepareConfig(config *chaakoo.Config) error {
+	if err := config.Validate(); err != nil {
+		return fmt.Errorf("validation errors found in the config: %w", err)
+	}
+	if err := config.Parse(); err != nil {
+		return fmt.Errorf("cannot parse the grid for a window: %w", err)
+	}
+	if err := config.ResolveWorkingDirectories(); err != nil {
+		return fmt.Errorf("cannot resolve the working directories: %w", err)
+	}
+	if err := config.LoadEnvFiles(); err != nil {
+		return fmt.Errorf("cannot load the env files: %w", err)
+	}
+	return nil
+}
+
+// applyFlags sets the flags of the command line on the config
+func applyFlags(config *chaakoo.Config) {
+	config.DryRun = dryRun
+	config.ExitOnError = exitOnError
+	config.Update = update
+	config.KeepOnError = keepOnError
+	config.Replace = replace
+	config.StopTimeout = stopTimeout
+	config.Attach = attach
+	config.Here = here
+	config.CurrentWindow = currentWin
+	config.ChangeDirectory = changeDir
+}
+
+// findDimension returns the dimension from the flags, or the dimension of the terminal if it is not passed
+func findDimension() *chaakoo.Dimension {
+	if height > 0 && width > 0 {
+		return chaakoo.NewDimension(width, height)
+	}
+	log.Debug().Msg("finding the dimensions")
+	dimUsingTerm := &chaakoo.DimensionUsingTerm{}
+	dimension, err := dimUsingTerm.Dimension()
+	if err != nil {
+		log.Fatal().Err(err).Msg("cannot find the terminal dimensions")
+	}
+	log.Debug().Int("width", dimension.Width).Int("height", dimension.Height).Msg("found dimensions")
+	return dimension
+}
+
+func fileExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
+
 func reconfigureLogger() {
 	timeFormat := time.Kitchen
 	if verboseLog {
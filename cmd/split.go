@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"strings"
+
+	"github.com/pallavJha/chaakoo"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+)
+
+var (
+	windowName string
+
+	splitCmd = &cobra.Command{
+		Use:   "split [grid]",
+		Short: "splits the current TMUX pane to match a grid",
+		Long: `splits the current TMUX pane to match a grid, the other panes of the current window are not changed
+The grid can be passed inline, with its rows separated by new lines or semicolons, or the grid and the commands of a
+window can be taken from the config with --window
+$ chaakoo split 'vim term; vim logs'`,
+		Args: cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			var config chaakoo.Config
+			var window *chaakoo.Window
+			if len(windowName) > 0 {
+				if len(args) > 0 {
+					log.Fatal().Msg("a grid and --window cannot be used together")
+				}
+				readConfig()
+				loadConfig(&config)
+				interpolateConfig(&config, true)
+				for _, configWindow := range config.Windows {
+					if configWindow.Name == windowName {
+						window = configWindow
+						break
+					}
+				}
+				if window == nil {
+					log.Fatal().Msgf("window, %s, is not present in the config", windowName)
+				}
+				config.Windows = []*chaakoo.Window{window}
+				if err := window.Validate(); err != nil {
+					log.Fatal().Err(err).Msg("validation errors found in the window")
+				}
+				// the when conditions of the window and of its panes are evaluated, like for the whole config
+				if err := config.Parse(); err != nil {
+					log.Fatal().Err(err).Msgf("cannot parse the window, %s", windowName)
+				}
+				if err := config.ResolveWorkingDirectories(); err != nil {
+					log.Fatal().Err(err).Msg("cannot resolve the working directories")
+				}
+				if err := config.LoadEnvFiles(); err != nil {
+					log.Fatal().Err(err).Msg("cannot load the env files")
+				}
+			} else if len(args) > 0 {
+				window = &chaakoo.Window{Name: "split", Grid: strings.ReplaceAll(args[0], ";", "\n")}
+				if err := window.Validate(); err != nil {
+					log.Fatal().Err(err).Msg("validation errors found in the window")
+				}
+				if err := window.Parse(); err != nil {
+					log.Fatal().Err(err).Msg("cannot parse the grid for the window")
+				}
+			} else {
+				log.Fatal().Msg("either a grid or --window is required")
+			}
+			config.DryRun = dryRun
+			config.ExitOnError = exitOnError
+			config.KeepOnError = keepOnError
+			config.ChangeDirectory = changeDir
+
+			wrapper := chaakoo.NewTmuxWrapper(&config, nil)
+			if err := wrapper.Split(window); err != nil {
+				log.Fatal().Err(err).Msg("error while splitting the current pane")
+			}
+			log.Info().Msgf("current pane is split for window, %s", window.Name)
+		},
+	}
+)
+
+func init() {
+	splitCmd.Flags().StringVarP(&windowName, "window", "n", "", "window of the config whose grid and commands are used")
+	rootCmd.AddCommand(splitCmd)
+}
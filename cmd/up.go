@@ -0,0 +1,102 @@
+package cmd
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/pallavJha/chaakoo"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+)
+
+var (
+	upCmd = &cobra.Command{
+		Use:   "up [session...]",
+		Short: "creates the sessions of the config",
+		Long: `creates the sessions of the config, or only the sessions whose names are passed
+The sessions are created at the same time and an error in one session does not stop the others, chaakoo exits with an
+error if a session cannot be created. The hooks of the config are run once, before and after the sessions, and the hooks
+of a session are run with the session
+$ chaakoo up frontend backend`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if replace && update {
+				log.Fatal().Msg("--replace and --update cannot be used together")
+			}
+			if here || currentWin {
+				log.Fatal().Msg("--here and --current-window cannot be used with up")
+			}
+			readConfig()
+			var config chaakoo.Config
+			loadConfig(&config)
+			interpolateConfig(&config, true)
+			sessions, err := config.SelectSessions(args)
+			if err != nil {
+				log.Fatal().Err(err).Msg("cannot select the sessions")
+			}
+			if attach && len(sessions) > 1 {
+				log.Fatal().Msg("--attach can only be used with one session")
+			}
+			dimension := findDimension()
+			applyFlags(&config)
+			runConfigHooks(&config, chaakoo.BeforeStartHook)
+
+			var errs = make([]error, len(sessions))
+			var wg sync.WaitGroup
+			for i, session := range sessions {
+				wg.Add(1)
+				go func(i int, session *chaakoo.Config) {
+					defer wg.Done()
+					errs[i] = upSession(session, dimension)
+				}(i, session)
+			}
+			wg.Wait()
+
+			var failed int
+			for i, session := range sessions {
+				if errs[i] != nil {
+					log.Error().Err(errs[i]).Msgf("cannot create the session, %s", session.SessionName)
+					failed++
+					continue
+				}
+				log.Info().Msgf("session, %s, is ready, it can be attached by executing: tmux a -t %s",
+					session.SessionName, session.SessionName)
+			}
+			if failed > 0 {
+				log.Fatal().Msgf("%d of %d sessions cannot be created", failed, len(sessions))
+			}
+			runConfigHooks(&config, chaakoo.AfterStartHook)
+			if attach {
+				if err = chaakoo.NewTmuxWrapper(sessions[0], dimension).Attach(); err != nil {
+					log.Fatal().Err(err).Msg("error while attaching the session")
+				}
+			}
+		},
+	}
+)
+
+// runConfigHooks runs the hooks of a config with sessions, the hooks of a config without sessions are run with its
+// session
+func runConfigHooks(config *chaakoo.Config, stage string) {
+	if len(config.Sessions) == 0 {
+		return
+	}
+	if err := chaakoo.NewTmuxWrapper(config, nil).RunHooks(stage); err != nil {
+		log.Fatal().Err(err).Msg("cannot run the hooks of the config")
+	}
+}
+
+// upSession prepares the config of the session and then creates the session
+func upSession(session *chaakoo.Config, dimension *chaakoo.Dimension) error {
+	if err := prepareConfig(session); err != nil {
+		return err
+	}
+	applyFlags(session)
+	if err := chaakoo.NewTmuxWrapper(session, dimension).Apply(); err != nil {
+		return fmt.Errorf("error while applying the config: %w", err)
+	}
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(upCmd)
+}
@@ -0,0 +1,129 @@
+package chaakoo
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Condition decides whether a window or a command is used, with when in the config. Every field of the condition that
+// is present must hold:
+// 	- env, the environment variables must have these values, an empty value only needs the variable to be set
+// 	- exists, the file or the directory must be present, a relative path is resolved against the Directory of the
+// 	config like the working directories
+// 	- hostname, the name of the host must be the same
+// 	- os, the operating system must be the same, like linux or darwin
+// 	- command, the probe command must exit with 0, it is executed with sh -c in the Directory of the config, even in
+// 	the dry runs
+// 	- not, the condition inside it must not hold
+type Condition struct {
+	Env      map[string]string `mapstructure:"env" yaml:"env,omitempty"`
+	Exists   string            `mapstructure:"exists" yaml:"exists,omitempty"`
+	Hostname string            `mapstructure:"hostname" yaml:"hostname,omitempty"`
+	OS       string            `mapstructure:"os" yaml:"os,omitempty"`
+	Command  string            `mapstructure:"command" yaml:"command,omitempty"`
+	Not      *Condition        `mapstructure:"not" yaml:"not,omitempty"`
+}
+
+// skipWindows removes the windows and the commands whose conditions do not hold, a pane whose command is removed is
+// still created but nothing is sent to it. It returns an error if every window of the config is removed.
+func (c *Config) skipWindows() error {
+	var windows []*Window
+	for _, window := range c.Windows {
+		holds, err := window.When.evaluate(c.Directory)
+		if err != nil {
+			return fmt.Errorf("invalid when for window, %s: %w", window.Name, err)
+		}
+		if !holds {
+			log.Debug().Msgf("skipping window, %s, as its when condition does not hold", window.Name)
+			continue
+		}
+		var commands []*Command
+		for _, command := range window.Commands {
+			holds, err = command.When.evaluate(c.Directory)
+			if err != nil {
+				return fmt.Errorf("invalid when for pane, %s, in window, %s: %w", command.Name, window.Name, err)
+			}
+			if !holds {
+				log.Debug().Msgf("skipping the command of pane, %s, in window, %s, as its when condition does not hold",
+					command.Name, window.Name)
+				continue
+			}
+			commands = append(commands, command)
+		}
+		window.Commands = commands
+		windows = append(windows, window)
+	}
+	if len(windows) == 0 && len(c.Windows) > 0 {
+		return fmt.Errorf("every window of session, %s, is skipped by its when condition", c.SessionName)
+	}
+	c.Windows = windows
+	return nil
+}
+
+// evaluate returns true if the condition holds, a nil condition always holds
+func (c *Condition) evaluate(directory string) (bool, error) {
+	if c == nil {
+		return true, nil
+	}
+	for _, name := range sortedNames(c.Env) {
+		value, ok := os.LookupEnv(name)
+		if !ok || (len(c.Env[name]) > 0 && value != c.Env[name]) {
+			return false, nil
+		}
+	}
+	if len(c.Exists) > 0 {
+		path, err := resolvePath(c.Exists, directory)
+		if err != nil {
+			return false, fmt.Errorf("invalid exists, %s: %w", c.Exists, err)
+		}
+		if _, err = os.Stat(path); err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				return false, nil
+			}
+			return false, fmt.Errorf("cannot check if %s exists: %w", path, err)
+		}
+	}
+	if len(c.Hostname) > 0 {
+		hostname, err := os.Hostname()
+		if err != nil {
+			return false, fmt.Errorf("cannot find the hostname: %w", err)
+		}
+		if hostname != c.Hostname {
+			return false, nil
+		}
+	}
+	if len(c.OS) > 0 && c.OS != runtime.GOOS {
+		return false, nil
+	}
+	if len(strings.TrimSpace(c.Command)) > 0 {
+		probe := exec.Command("sh", "-c", c.Command)
+		probe.Dir = directory
+		log.Debug().Str("command", probe.String()).Msg("executing the probe command...")
+		if err := probe.Run(); err != nil {
+			log.Debug().Err(err).Msgf("probe command, %s, failed", c.Command)
+			return false, nil
+		}
+	}
+	if c.Not != nil {
+		holds, err := c.Not.evaluate(directory)
+		return !holds, err
+	}
+	return true, nil
+}
+
+func (i *interpolator) interpolateCondition(condition *Condition) {
+	for condition != nil {
+		i.interpolateEnvironment(condition.Env)
+		condition.Exists = i.interpolate(condition.Exists)
+		condition.Hostname = i.interpolate(condition.Hostname)
+		condition.OS = i.interpolate(condition.OS)
+		condition.Command = i.interpolate(condition.Command)
+		condition = condition.Not
+	}
+}
@@ -0,0 +1,41 @@
+package chaakoo
+
+import (
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v2"
+	"testing"
+)
+
+type ConditionTestCase struct {
+	ID      int
+	Config  string            // config as yaml
+	Env     map[string]string // environment variables that are set for the test case, an empty value unsets them
+	Windows string            // expected windows as yaml
+	Error   string
+}
+
+func (s ConditionSuite) testSkipWindows(t *testing.T) {
+	var testCases []ConditionTestCase
+	if err := viper.UnmarshalKey("conditions", &testCases); err != nil {
+		t.Log("unable to read from the config", err)
+		t.Fail()
+	}
+	for _, testCase := range testCases {
+		t.Log("Test case", testCase.ID)
+		var config Config
+		require.NoError(t, yaml.Unmarshal([]byte(testCase.Config), &config))
+		config.Directory = TestConfigDirName
+		resetEnv := setEnv(testCase.Env)
+		err := config.skipWindows()
+		resetEnv()
+		if len(testCase.Error) > 0 {
+			require.EqualError(t, err, testCase.Error)
+			continue
+		}
+		require.NoError(t, err)
+		content, err := yaml.Marshal(config.Windows)
+		require.NoError(t, err)
+		require.Equal(t, testCase.Windows, string(content))
+	}
+}
@@ -2,7 +2,12 @@ package chaakoo
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
 	"strings"
+	"time"
 )
 
 import (
@@ -11,10 +16,41 @@ import (
 
 // Config holds the entire config
 type Config struct {
-	SessionName string    `mapstructure:"name"`
-	Windows     []*Window `mapstructure:"windows"`
-	DryRun      bool
-	ExitOnError bool
+	SessionName string    `mapstructure:"name" yaml:"name"`
+	Windows     []*Window `mapstructure:"windows" yaml:"windows"`
+	DryRun      bool      `yaml:"-"`
+	ExitOnError bool      `yaml:"-"`
+	Update      bool      `yaml:"-"`
+	KeepOnError bool      `yaml:"-"`
+	Replace     bool      `yaml:"-"`
+	Attach      bool      `yaml:"-"`
+	Here        bool      `yaml:"-"`
+	// CurrentWindow applies the first window on the current window, it is used with Here
+	CurrentWindow bool `yaml:"-"`
+	// StopTimeout is the time for which the commands of a replaced session are given to stop after C-c
+	StopTimeout time.Duration `yaml:"-"`
+	// Directory is the directory of the config file, the relative working directories are resolved against it
+	Directory string `yaml:"-"`
+	// ChangeDirectory sends cd to the panes instead of creating them in their working directories
+	ChangeDirectory bool `yaml:"-"`
+	// Env is the environment of the session, every pane of the session gets it
+	Env map[string]string `mapstructure:"env" yaml:"env,omitempty"`
+	// EnvFile is a dotenv file whose variables are added to the Env, see Config.LoadEnvFiles
+	EnvFile string `mapstructure:"env_file" yaml:"env_file,omitempty"`
+	// Vars are the default values of the variables of the config, see Config.Interpolate
+	Vars map[string]string `mapstructure:"vars" yaml:"vars,omitempty"`
+	// Inputs are the variables of the config that are asked when chaakoo starts, see Config.ResolveInputs
+	Inputs []*Input `mapstructure:"inputs" yaml:"inputs,omitempty"`
+	// Extends is the config file that this config is based on, see LoadConfig
+	Extends string `mapstructure:"extends" yaml:"extends,omitempty"`
+	// Include are the config files, like the files with the shared windows, that are merged into this config
+	Include []string `mapstructure:"include" yaml:"include,omitempty"`
+	// Sessions are used instead of the Windows to create more than one session from a config, see Config.SelectSessions
+	Sessions []*Config `mapstructure:"sessions" yaml:"sessions,omitempty"`
+	// Profiles alter the config when they are selected, see Config.ApplyProfile
+	Profiles []*Profile `mapstructure:"profiles" yaml:"profiles,omitempty"`
+	// Hooks are the commands that are run on the host around the start and the stop of the session, see Hooks
+	Hooks *Hooks `mapstructure:"hooks" yaml:"hooks,omitempty"`
 }
 
 // Validate validates the config
@@ -29,16 +65,26 @@ func (c *Config) Validate() error {
 	if len(c.Windows) == 0 {
 		return fmt.Errorf("atleast 1 window is required for session - %s", c.SessionName)
 	}
+	if err := validateEnvironment(c.Env); err != nil {
+		return fmt.Errorf("invalid env for session, %s: %w", c.SessionName, err)
+	}
 	for _, window := range c.Windows {
 		if err := window.Validate(); err != nil {
 			return err
 		}
 	}
+	if _, err := commandDependencies(c.Windows, false); err != nil {
+		return err
+	}
 	return nil
 }
 
-// Parse delegates to Window.Parse
+// Parse removes the windows and the commands whose when conditions do not hold, see Condition, and then delegates to
+// Window.Parse
 func (c *Config) Parse() error {
+	if err := c.skipWindows(); err != nil {
+		return err
+	}
 	for _, window := range c.Windows {
 		if err := window.Parse(); err != nil {
 			return fmt.Errorf("unable to parse grid for window - %s: %w", window.Name, err)
@@ -47,12 +93,142 @@ func (c *Config) Parse() error {
 	return nil
 }
 
+// ResolveWorkingDirectories expands the ~ and the environment variables in the working directories of the commands and
+// then makes them absolute, a relative working directory is resolved against the Directory of the config or against
+// the current working directory if the Directory is empty. It returns an error if a working directory is not present.
+func (c *Config) ResolveWorkingDirectories() error {
+	for _, window := range c.Windows {
+		for _, command := range window.Commands {
+			workingDirectory, err := resolveDirectory(command.WorkingDirectory, c.Directory)
+			if err != nil {
+				return fmt.Errorf("invalid workdir for pane, %s, in window, %s: %w", command.Name, window.Name, err)
+			}
+			command.WorkingDirectory = workingDirectory
+		}
+	}
+	return nil
+}
+
+// LoadEnvFiles reads the env files of the session, the windows and the panes and adds their variables to the env of
+// the same level, the env written in the config overrides the env file. The env files are resolved like the working
+// directories, see Config.ResolveWorkingDirectories.
+// So the value of a variable in a pane comes from the first of these that has it:
+// 	- env of the pane
+// 	- env_file of the pane
+// 	- env of the window
+// 	- env_file of the window
+// 	- env of the session
+// 	- env_file of the session
+func (c *Config) LoadEnvFiles() error {
+	environment, _, err := loadEnvFile(c.EnvFile, c.Directory, c.Env)
+	if err != nil {
+		return fmt.Errorf("invalid env_file for session, %s: %w", c.SessionName, err)
+	}
+	c.Env = environment
+	for _, window := range c.Windows {
+		if window.Env, window.envFileNames, err = loadEnvFile(window.EnvFile, c.Directory, window.Env); err != nil {
+			return fmt.Errorf("invalid env_file for window, %s: %w", window.Name, err)
+		}
+		for _, command := range window.Commands {
+			if command.Env, command.envFileNames, err = loadEnvFile(command.EnvFile, c.Directory,
+				command.Env); err != nil {
+				return fmt.Errorf("invalid env_file for pane, %s, in window, %s: %w", command.Name, window.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// loadEnvFile returns the variables of the env file overridden by the environment, and the names of the variables
+// that are taken from the env file
+func loadEnvFile(envFile, baseDirectory string, environment map[string]string) (map[string]string, map[string]bool,
+	error) {
+	if len(strings.TrimSpace(envFile)) == 0 {
+		return environment, nil, nil
+	}
+	envFile, err := resolvePath(strings.TrimSpace(envFile), baseDirectory)
+	if err != nil {
+		return nil, nil, err
+	}
+	fileEnvironment, err := readEnvFile(envFile)
+	if err != nil {
+		return nil, nil, err
+	}
+	var fileNames = make(map[string]bool)
+	for name := range fileEnvironment {
+		if _, ok := environment[name]; !ok {
+			fileNames[name] = true
+		}
+	}
+	for name, value := range environment {
+		fileEnvironment[name] = value
+	}
+	return fileEnvironment, fileNames, nil
+}
+
+func resolveDirectory(directory, baseDirectory string) (string, error) {
+	directory = strings.TrimSpace(directory)
+	if len(directory) == 0 {
+		return "", nil
+	}
+	directory, err := resolvePath(directory, baseDirectory)
+	if err != nil {
+		return "", err
+	}
+	info, err := os.Stat(directory)
+	if err != nil {
+		return "", fmt.Errorf("cannot find the directory, %s: %w", directory, err)
+	}
+	if !info.IsDir() {
+		return "", fmt.Errorf("%s is not a directory", directory)
+	}
+	return directory, nil
+}
+
+// resolvePath expands the ~ and the environment variables in the path and then makes it absolute
+func resolvePath(path, baseDirectory string) (string, error) {
+	var missingVariable string
+	path = os.Expand(path, func(name string) string {
+		value, ok := os.LookupEnv(name)
+		if !ok && len(missingVariable) == 0 {
+			missingVariable = name
+		}
+		return value
+	})
+	if len(missingVariable) > 0 {
+		return "", fmt.Errorf("environment variable, %s, is not set", missingVariable)
+	}
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		homeDirectory, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("cannot find the home directory: %w", err)
+		}
+		path = filepath.Join(homeDirectory, path[1:])
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(baseDirectory, path)
+	}
+	absolutePath, err := filepath.Abs(path)
+	if err != nil {
+		return "", fmt.Errorf("cannot find the absolute path of %s: %w", path, err)
+	}
+	return absolutePath, nil
+}
+
 // Window represents one TMUX window from the config
 type Window struct {
-	Name      string `mapstructure:"name"`
-	Grid      string `mapstructure:"grid"`
-	FirstPane *Pane
-	Commands  []*Command `mapstructure:"commands"`
+	Name      string     `mapstructure:"name" yaml:"name"`
+	Grid      string     `mapstructure:"grid" yaml:"grid"`
+	FirstPane *Pane      `yaml:"-"`
+	Commands  []*Command `mapstructure:"commands" yaml:"commands,omitempty"`
+	// Env is the environment of the panes of the window, it overrides the environment of the session
+	Env map[string]string `mapstructure:"env" yaml:"env,omitempty"`
+	// EnvFile is a dotenv file whose variables are added to the Env, see Config.LoadEnvFiles
+	EnvFile string `mapstructure:"env_file" yaml:"env_file,omitempty"`
+	// When is the condition of the window, the window is skipped if it does not hold, see Config.Parse
+	When *Condition `mapstructure:"when" yaml:"when,omitempty"`
+	// envFileNames are the names of the variables of the Env that are taken from the EnvFile
+	envFileNames map[string]bool
 }
 
 // Validate validates a Window related config
@@ -66,9 +242,47 @@ func (w *Window) Validate() error {
 	if len(strings.TrimSpace(w.Grid)) == 0 {
 		return fmt.Errorf("grid for window, %s, is empty", w.Name)
 	}
+	if err := validateEnvironment(w.Env); err != nil {
+		return fmt.Errorf("invalid env for window, %s: %w", w.Name, err)
+	}
+	for _, command := range w.Commands {
+		if len(strings.TrimSpace(command.CommandText)) > 0 && len(strings.TrimSpace(command.Script)) > 0 {
+			return fmt.Errorf("pane, %s, of window, %s, can have either a command or a script", command.Name, w.Name)
+		}
+		if err := validateEnvironment(command.Env); err != nil {
+			return fmt.Errorf("invalid env for pane, %s, of window, %s: %w", command.Name, w.Name, err)
+		}
+		for _, condition := range command.WaitFor {
+			if err := condition.validate(); err != nil {
+				return fmt.Errorf("invalid wait_for for pane, %s, of window, %s: %w", command.Name, w.Name, err)
+			}
+		}
+	}
 	return nil
 }
 
+var environmentVariableName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// validateEnvironment checks the names of the environment variables, TMUX takes them as NAME=value
+func validateEnvironment(environment map[string]string) error {
+	for _, name := range sortedNames(environment) {
+		if !environmentVariableName.MatchString(name) {
+			return fmt.Errorf("invalid environment variable name, %s", name)
+		}
+	}
+	return nil
+}
+
+// sortedNames returns the names of the environment variables in order, so the TMUX commands are always the same
+func sortedNames(environment map[string]string) []string {
+	var names = make([]string, 0, len(environment))
+	for name := range environment {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
 // Parse - parses the config
 func (w *Window) Parse() error {
 	if w == nil {
@@ -88,12 +302,32 @@ func (w *Window) Parse() error {
 
 // Command represents a command fragment that will be executed in the pane whose name will be same as name in this
 // struct.
+// The lines of CommandText are typed in the pane as they are, each followed by the Enter key. The lines of Keys are
+// the TMUX key names, like C-c or Escape, separated by spaces, they are sent before the CommandText.
+// Script is run as one script in a new process of the pane's shell, it can be used instead of the CommandText for the
+// blocks that cannot be typed line by line, see TmuxWrapper.runScript.
+// Env is the environment of the pane, it overrides the environment of the window and the session. The environments
+// are passed to TMUX with the -e flag of the commands that create the session and the panes, see
+// TmuxWrapper.paneEnvironment. The variables of EnvFile are added to the Env, see Config.LoadEnvFiles.
 // WorkingDirectory is the location in which all the commands will be executed.
-// The working directory can be passed to tmux split-window command with -c flag but doing that will not create the
-// pane if the working directory is wrong. So, in this implementation, passing the working directory is deferred until
-// the pane has been created.
+// The working directory is passed to tmux split-window command with -c flag, TMUX does not create the pane if the
+// working directory is wrong so the working directories are checked before the session is created, see
+// Config.ResolveWorkingDirectories.
 type Command struct {
-	Name             string `mapstructure:"pane"`
-	CommandText      string `mapstructure:"command"`
-	WorkingDirectory string `mapstructure:"workdir"`
+	Name             string            `mapstructure:"pane" yaml:"pane"`
+	CommandText      string            `mapstructure:"command" yaml:"command,omitempty"`
+	Keys             string            `mapstructure:"keys" yaml:"keys,omitempty"`
+	Script           string            `mapstructure:"script" yaml:"script,omitempty"`
+	WorkingDirectory string            `mapstructure:"workdir" yaml:"workdir,omitempty"`
+	Env              map[string]string `mapstructure:"env" yaml:"env,omitempty"`
+	EnvFile          string            `mapstructure:"env_file" yaml:"env_file,omitempty"`
+	When             *Condition        `mapstructure:"when" yaml:"when,omitempty"`
+	// WaitFor are the conditions that must hold before the commands of the next panes are sent, see WaitCondition
+	WaitFor []*WaitCondition `mapstructure:"wait_for" yaml:"wait_for,omitempty"`
+	// DependsOn are the panes whose commands are sent before the command of this pane, see commandDependencies
+	DependsOn []string `mapstructure:"depends_on" yaml:"depends_on,omitempty"`
+	// Stop is sent to the pane to stop its command, like C-c or make stop, see TmuxWrapper.sendStop
+	Stop string `mapstructure:"stop" yaml:"stop,omitempty"`
+	// envFileNames are the names of the variables of the Env that are taken from the EnvFile
+	envFileNames map[string]bool
 }
@@ -0,0 +1,242 @@
+package chaakoo
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// LoadConfig reads the config file along with the files that it extends and includes, and sets the Directory of the
+// config to the directory of the file.
+// A config file is merged in this order, a file later in the order overrides the ones before it, see Config.merge:
+// 	- the config that it extends, with extends
+// 	- the configs that it includes, with include, in the same order
+// 	- the config file itself
+// The files that are extended or included can extend and include other files. Their paths are relative to the file in
+// which they are written, but the paths inside them, like the working directories, are relative to the Directory.
+// The override files, like the local config that is not committed, are loaded in the same way and then merged into
+// the config in their order.
+func LoadConfig(path string, overridePaths ...string) (*Config, error) {
+	config, err := loadConfigFile(path, nil)
+	if err != nil {
+		return nil, err
+	}
+	for _, overridePath := range overridePaths {
+		override, err := loadConfigFile(overridePath, nil)
+		if err != nil {
+			return nil, err
+		}
+		config.merge(override)
+	}
+	config.Directory = filepath.Dir(path)
+	return config, nil
+}
+
+// LocalConfigPath returns the path of the local config of a config file, it is next to the config file and it has
+// .local before the extension, like chaakoo.local.yaml for chaakoo.yaml
+func LocalConfigPath(path string) string {
+	extension := filepath.Ext(path)
+	return strings.TrimSuffix(path, extension) + ".local" + extension
+}
+
+// loadConfigFile loads the config file, the files that are being loaded are used to find the cycles
+func loadConfigFile(path string, loading []string) (*Config, error) {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot find the absolute path of %s: %w", path, err)
+	}
+	for _, loadingPath := range loading {
+		if loadingPath == path {
+			return nil, fmt.Errorf("config file, %s, includes itself: %s", path,
+				strings.Join(append(loading, path), " -> "))
+		}
+	}
+	loading = append(loading, path)
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read the config file, %s: %w", path, err)
+	}
+	var fileConfig Config
+	if err = yaml.Unmarshal(content, &fileConfig); err != nil {
+		return nil, fmt.Errorf("cannot parse the config file, %s: %w", path, err)
+	}
+
+	directory := filepath.Dir(path)
+	var config = &Config{}
+	if len(fileConfig.Extends) > 0 {
+		basePath, err := resolvePath(fileConfig.Extends, directory)
+		if err != nil {
+			return nil, fmt.Errorf("invalid extends in %s: %w", path, err)
+		}
+		if config, err = loadConfigFile(basePath, loading); err != nil {
+			return nil, err
+		}
+	}
+	for _, include := range fileConfig.Include {
+		includePath, err := resolvePath(include, directory)
+		if err != nil {
+			return nil, fmt.Errorf("invalid include in %s: %w", path, err)
+		}
+		includedConfig, err := loadConfigFile(includePath, loading)
+		if err != nil {
+			return nil, err
+		}
+		config.merge(includedConfig)
+	}
+	config.merge(&fileConfig)
+	return config, nil
+}
+
+// merge merges the override into the config:
+// 	- the name and the env_file are replaced if they are present in the override
+// 	- the hooks are replaced by the stages that are present in the override
+// 	- the env and the vars are merged by the names of the variables
+// 	- the inputs and the profiles are replaced by the ones with the same name
+// 	- the windows are merged by their names, see Window.merge, and the new windows are added after the others
+// 	- the sessions are merged by their names in the same way
+func (c *Config) merge(override *Config) {
+	if len(override.SessionName) > 0 {
+		c.SessionName = override.SessionName
+	}
+	if len(override.EnvFile) > 0 {
+		c.EnvFile = override.EnvFile
+	}
+	c.Hooks = c.Hooks.merge(override.Hooks)
+	c.Env = mergeVariables(c.Env, override.Env)
+	c.Vars = mergeVariables(c.Vars, override.Vars)
+	for _, input := range override.Inputs {
+		var replaced bool
+		for i, currentInput := range c.Inputs {
+			if currentInput.Name == input.Name {
+				c.Inputs[i] = input
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			c.Inputs = append(c.Inputs, input)
+		}
+	}
+	for _, profile := range override.Profiles {
+		var replaced bool
+		for i, currentProfile := range c.Profiles {
+			if currentProfile.Name == profile.Name {
+				c.Profiles[i] = profile
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			c.Profiles = append(c.Profiles, profile)
+		}
+	}
+	for _, window := range override.Windows {
+		var merged bool
+		for _, currentWindow := range c.Windows {
+			if currentWindow.Name == window.Name {
+				currentWindow.merge(window)
+				merged = true
+				break
+			}
+		}
+		if !merged {
+			c.Windows = append(c.Windows, window)
+		}
+	}
+	for _, session := range override.Sessions {
+		var merged bool
+		for _, currentSession := range c.Sessions {
+			if currentSession.SessionName == session.SessionName {
+				currentSession.merge(session)
+				merged = true
+				break
+			}
+		}
+		if !merged {
+			c.Sessions = append(c.Sessions, session)
+		}
+	}
+}
+
+// merge merges the override into the window, the grid, the env_file and the when are replaced if they are present in
+// the override, the env is merged by the names of the variables and the commands are merged by their panes, see
+// Command.merge
+func (w *Window) merge(override *Window) {
+	if len(strings.TrimSpace(override.Grid)) > 0 {
+		w.Grid = override.Grid
+	}
+	if len(override.EnvFile) > 0 {
+		w.EnvFile = override.EnvFile
+	}
+	if override.When != nil {
+		w.When = override.When
+	}
+	w.Env = mergeVariables(w.Env, override.Env)
+	for _, command := range override.Commands {
+		var merged bool
+		for _, currentCommand := range w.Commands {
+			if currentCommand.Name == command.Name {
+				currentCommand.merge(command)
+				merged = true
+				break
+			}
+		}
+		if !merged {
+			w.Commands = append(w.Commands, command)
+		}
+	}
+}
+
+// merge merges the override into the command, the fields that are present in the override, like the depends_on, are
+// replaced and the env is merged by the names of the variables. The command and the script replace each other as a
+// pane can only have one.
+func (c *Command) merge(override *Command) {
+	if len(strings.TrimSpace(override.CommandText)) > 0 {
+		c.CommandText = override.CommandText
+		c.Script = ""
+	}
+	if len(strings.TrimSpace(override.Script)) > 0 {
+		c.Script = override.Script
+		c.CommandText = ""
+	}
+	if len(strings.TrimSpace(override.Keys)) > 0 {
+		c.Keys = override.Keys
+	}
+	if len(strings.TrimSpace(override.Stop)) > 0 {
+		c.Stop = override.Stop
+	}
+	if len(override.WorkingDirectory) > 0 {
+		c.WorkingDirectory = override.WorkingDirectory
+	}
+	if len(override.EnvFile) > 0 {
+		c.EnvFile = override.EnvFile
+	}
+	if override.When != nil {
+		c.When = override.When
+	}
+	if len(override.WaitFor) > 0 {
+		c.WaitFor = override.WaitFor
+	}
+	if len(override.DependsOn) > 0 {
+		c.DependsOn = override.DependsOn
+	}
+	c.Env = mergeVariables(c.Env, override.Env)
+}
+
+// mergeVariables returns the variables of both the maps, the override wins if a variable is present in both
+func mergeVariables(variables, override map[string]string) map[string]string {
+	if len(override) == 0 {
+		return variables
+	}
+	var merged = make(map[string]string)
+	for name, value := range variables {
+		merged[name] = value
+	}
+	for name, value := range override {
+		merged[name] = value
+	}
+	return merged
+}
@@ -0,0 +1,45 @@
+package chaakoo
+
+import (
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v2"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type ConfigFileTestCase struct {
+	ID     int
+	Path   string
+	Local  bool   // if true then the local config of the path is merged
+	Config string // expected config as yaml
+	Error  string // {dir} is replaced by the absolute directory of the path
+}
+
+func (c ConfigFileSuite) testLoadConfig(t *testing.T) {
+	var testCases []ConfigFileTestCase
+	if err := viper.UnmarshalKey("configFiles", &testCases); err != nil {
+		t.Log("unable to read from the config", err)
+		t.Fail()
+	}
+	for _, testCase := range testCases {
+		t.Log("Test case", testCase.ID)
+		var overridePaths []string
+		if testCase.Local {
+			overridePaths = append(overridePaths, LocalConfigPath(testCase.Path))
+		}
+		config, err := LoadConfig(testCase.Path, overridePaths...)
+		if len(testCase.Error) > 0 {
+			directory, absErr := filepath.Abs(filepath.Dir(testCase.Path))
+			require.NoError(t, absErr)
+			require.EqualError(t, err, strings.ReplaceAll(testCase.Error, "{dir}", directory))
+			continue
+		}
+		require.NoError(t, err)
+		require.Equal(t, filepath.Dir(testCase.Path), config.Directory)
+		content, err := yaml.Marshal(config)
+		require.NoError(t, err)
+		require.Equal(t, testCase.Config, string(content))
+	}
+}
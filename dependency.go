@@ -0,0 +1,220 @@
+package chaakoo
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/rs/zerolog/log"
+)
+
+// pendingWindow is a window whose commands are sent after every window is created, see TmuxWrapper.runCommandGraph
+type pendingWindow struct {
+	window    *Window
+	paneNames map[string]string
+}
+
+// commandResult is closed when the command has been sent, or when it cannot be sent
+type commandResult struct {
+	window *Window
+	done   chan struct{}
+	err    error
+}
+
+// hasDependencies returns true if a command of the config depends on another command
+func (c *Config) hasDependencies() bool {
+	for _, window := range c.Windows {
+		for _, command := range window.Commands {
+			if len(command.DependsOn) > 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// commandDependencies returns the commands that each command of the windows depends on, with depends_on in the config.
+// A dependency is the name of a pane with a command:
+// 	- in the same window
+// 	- or in another window, if only one window has a pane with that name
+// 	- or in the window whose name is written before it, like logs:tail
+// It returns an error if a dependency is not present or if the dependencies have a cycle. If ignoreMissing is true then
+// the dependencies that are not present are ignored, like the commands that are skipped by their when conditions.
+func commandDependencies(windows []*Window, ignoreMissing bool) (map[*Command][]*Command, error) {
+	var dependencies = make(map[*Command][]*Command)
+	for _, window := range windows {
+		for _, command := range window.Commands {
+			for _, name := range command.DependsOn {
+				dependency, err := findDependency(windows, window, name)
+				if err != nil && !ignoreMissing {
+					return nil, fmt.Errorf("pane, %s, of window, %s, depends on, %s, %w", command.Name, window.Name,
+						name, err)
+				}
+				if dependency != nil {
+					dependencies[command] = append(dependencies[command], dependency)
+				}
+			}
+		}
+	}
+	if cycle := findCycle(windows, dependencies); len(cycle) > 0 {
+		return nil, fmt.Errorf("depends_on has a cycle: %s", strings.Join(cycle, " -> "))
+	}
+	return dependencies, nil
+}
+
+func findDependency(windows []*Window, window *Window, name string) (*Command, error) {
+	if separator := strings.LastIndex(name, ":"); separator >= 0 {
+		for _, dependencyWindow := range windows {
+			if dependencyWindow.Name == name[:separator] {
+				if command := findCommand(dependencyWindow, name[separator+1:]); command != nil {
+					return command, nil
+				}
+			}
+		}
+		return nil, errors.New("which is not a pane with a command")
+	}
+	if command := findCommand(window, name); command != nil {
+		return command, nil
+	}
+	var found *Command
+	for _, dependencyWindow := range windows {
+		if command := findCommand(dependencyWindow, name); command != nil {
+			if found != nil {
+				return nil, errors.New("which is present in more than one window, it can be written as window:pane")
+			}
+			found = command
+		}
+	}
+	if found == nil {
+		return nil, errors.New("which is not a pane with a command")
+	}
+	return found, nil
+}
+
+func findCommand(window *Window, paneName string) *Command {
+	for _, command := range window.Commands {
+		if command.Name == paneName {
+			return command
+		}
+	}
+	return nil
+}
+
+// findCycle returns the panes of the first cycle in the dependencies, as window:pane, or nil if there is no cycle
+func findCycle(windows []*Window, dependencies map[*Command][]*Command) []string {
+	var names = make(map[*Command]string)
+	for _, window := range windows {
+		for _, command := range window.Commands {
+			names[command] = window.Name + ":" + command.Name
+		}
+	}
+	const (
+		visiting = 1
+		visited  = 2
+	)
+	var states = make(map[*Command]int)
+	var path []*Command
+	var visit func(command *Command) []string
+	visit = func(command *Command) []string {
+		switch states[command] {
+		case visited:
+			return nil
+		case visiting:
+			var cycle []string
+			for i := len(path) - 1; i >= 0; i-- {
+				cycle = append([]string{names[path[i]]}, cycle...)
+				if path[i] == command {
+					break
+				}
+			}
+			return append(cycle, names[command])
+		}
+		states[command] = visiting
+		path = append(path, command)
+		for _, dependency := range dependencies[command] {
+			if cycle := visit(dependency); cycle != nil {
+				return cycle
+			}
+		}
+		path = path[:len(path)-1]
+		states[command] = visited
+		return nil
+	}
+	for _, window := range windows {
+		for _, command := range window.Commands {
+			if cycle := visit(command); cycle != nil {
+				return cycle
+			}
+		}
+	}
+	return nil
+}
+
+// runCommandGraph sends the commands of the pending windows in the order of their dependencies. A command is sent when
+// the commands that it depends on have been sent and their wait_for conditions hold, the commands that do not depend on
+// each other are sent at the same time. A command is not sent if a command that it depends on fails.
+// The errors are handled like the errors of TmuxWrapper.handleRunCommands.
+func (t *TmuxWrapper) runCommandGraph() error {
+	pending := t.pending
+	t.pending = nil
+	if len(pending) == 0 {
+		return nil
+	}
+	var windows []*Window
+	for _, pendingWindow := range pending {
+		windows = append(windows, pendingWindow.window)
+	}
+	dependencies, err := commandDependencies(windows, true)
+	if err != nil {
+		return err
+	}
+	var results = make(map[*Command]*commandResult)
+	for _, window := range windows {
+		for _, command := range window.Commands {
+			results[command] = &commandResult{window: window, done: make(chan struct{})}
+		}
+	}
+
+	var wg sync.WaitGroup
+	for _, pendingWindow := range pending {
+		for _, command := range pendingWindow.window.Commands {
+			wg.Add(1)
+			go func(window *Window, paneNames map[string]string, command *Command) {
+				defer wg.Done()
+				result := results[command]
+				defer close(result.done)
+				for _, dependency := range dependencies[command] {
+					dependencyResult := results[dependency]
+					<-dependencyResult.done
+					if dependencyResult.err != nil {
+						result.err = fmt.Errorf("pane, %s, in window, %s, is not started as pane, %s, in window, %s, "+
+							"failed", command.Name, window.Name, dependency.Name, dependencyResult.window.Name)
+						return
+					}
+				}
+				paneID, ok := paneNames[command.Name]
+				if !ok {
+					return
+				}
+				log.Debug().Msgf("sending the commands of pane, %s, in window, %s", command.Name, window.Name)
+				result.err = t.runCommand(window, paneID, command)
+			}(pendingWindow.window, pendingWindow.paneNames, command)
+		}
+	}
+	wg.Wait()
+
+	for _, window := range windows {
+		for _, command := range window.Commands {
+			if err = results[command].err; err == nil {
+				continue
+			}
+			if t.config.ExitOnError {
+				log.Error().Err(err).Msgf("error while executing the commands for windows %s", window.Name)
+				return fmt.Errorf("error while executing the commands for windows %s: %w", window.Name, err)
+			}
+			log.Debug().Err(err).Msgf("error while executing the commands for windows %s", window.Name)
+		}
+	}
+	return nil
+}
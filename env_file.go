@@ -0,0 +1,112 @@
+package chaakoo
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strings"
+)
+
+// readEnvFile reads the variables of a dotenv file, see parseEnvFile
+func readEnvFile(path string) (map[string]string, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read the env file, %s: %w", path, err)
+	}
+	environment, err := parseEnvFile(string(content))
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse the env file, %s: %w", path, err)
+	}
+	return environment, nil
+}
+
+// parseEnvFile parses the content of a dotenv file, it has one NAME=value on each line:
+// 	- the empty lines and the lines starting with # are skipped, and the lines can start with export
+// 	- an unquoted value ends at the first # that follows a space and the spaces around it are removed
+// 	- a value in single quotes is taken as it is
+// 	- a value in double quotes can have the escapes \n, \t, \r, \" and \\
+// The values are not expanded, so $NAME stays as it is, and a value cannot span multiple lines.
+// If a variable is present more than once then the last value is used.
+func parseEnvFile(content string) (map[string]string, error) {
+	var environment = make(map[string]string)
+	for i, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if len(line) == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if strings.HasPrefix(line, "export ") {
+			line = strings.TrimSpace(strings.TrimPrefix(line, "export "))
+		}
+		separatorIndex := strings.Index(line, "=")
+		if separatorIndex < 0 {
+			return nil, fmt.Errorf("line %d: expected NAME=value", i+1)
+		}
+		name := strings.TrimSpace(line[:separatorIndex])
+		if !environmentVariableName.MatchString(name) {
+			return nil, fmt.Errorf("line %d: invalid environment variable name, %s", i+1, name)
+		}
+		value, err := parseEnvValue(strings.TrimSpace(line[separatorIndex+1:]))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", i+1, err)
+		}
+		environment[name] = value
+	}
+	return environment, nil
+}
+
+func parseEnvValue(value string) (string, error) {
+	if strings.HasPrefix(value, "'") {
+		endIndex := strings.Index(value[1:], "'")
+		if endIndex < 0 {
+			return "", errors.New("single quoted value is not closed")
+		}
+		return value[1 : endIndex+1], checkEnvValueEnd(value[endIndex+2:])
+	}
+	if strings.HasPrefix(value, `"`) {
+		var builder strings.Builder
+		for i := 1; i < len(value); i++ {
+			switch value[i] {
+			case '"':
+				return builder.String(), checkEnvValueEnd(value[i+1:])
+			case '\\':
+				if i+1 == len(value) {
+					return "", errors.New("double quoted value is not closed")
+				}
+				i++
+				switch value[i] {
+				case 'n':
+					builder.WriteByte('\n')
+				case 't':
+					builder.WriteByte('\t')
+				case 'r':
+					builder.WriteByte('\r')
+				case '"', '\\':
+					builder.WriteByte(value[i])
+				default:
+					builder.WriteByte('\\')
+					builder.WriteByte(value[i])
+				}
+			default:
+				builder.WriteByte(value[i])
+			}
+		}
+		return "", errors.New("double quoted value is not closed")
+	}
+	// a # after a space or a tab starts a comment, a#b is a value
+	for i := 1; i < len(value); i++ {
+		if value[i] == '#' && (value[i-1] == ' ' || value[i-1] == '\t') {
+			value = value[:i]
+			break
+		}
+	}
+	return strings.TrimSpace(value), nil
+}
+
+// checkEnvValueEnd checks the text after a quoted value, only a comment can follow it
+func checkEnvValueEnd(text string) error {
+	text = strings.TrimSpace(text)
+	if len(text) > 0 && !strings.HasPrefix(text, "#") {
+		return fmt.Errorf("unexpected text after the quoted value, %s", text)
+	}
+	return nil
+}
@@ -0,0 +1,32 @@
+package chaakoo
+
+import (
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+type EnvFileTestCase struct {
+	ID      int
+	Content string
+	Env     map[string]string
+	Error   string
+}
+
+func (e EnvFileSuite) testParseEnvFile(t *testing.T) {
+	var testCases []EnvFileTestCase
+	if err := viper.UnmarshalKey("envFiles", &testCases); err != nil {
+		t.Log("unable to read from the config", err)
+		t.Fail()
+	}
+	for _, testCase := range testCases {
+		t.Log("Test case", testCase.ID)
+		environment, err := parseEnvFile(testCase.Content)
+		if len(testCase.Error) > 0 {
+			require.EqualError(t, err, testCase.Error)
+			continue
+		}
+		require.NoError(t, err)
+		require.Equal(t, testCase.Env, environment)
+	}
+}
@@ -0,0 +1,108 @@
+package chaakoo
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+)
+
+// shells are not frozen as commands because every pane starts with one
+var shells = map[string]bool{
+	"bash": true,
+	"zsh":  true,
+	"sh":   true,
+	"fish": true,
+	"dash": true,
+	"ksh":  true,
+	"csh":  true,
+	"tcsh": true,
+}
+
+var invalidPaneNameChars = regexp.MustCompile(`\s+`)
+
+// Freeze reads the running session, with the name present in the config, and converts its windows and panes into the
+// config.
+// The grids are prepared from the window layouts with the provided tolerance, see LayoutCell.AsGrid. The panes are
+// named after their names given by chaakoo, their titles or their current commands and the current path and command
+// of a pane are kept as its workdir and command.
+func (t *TmuxWrapper) Freeze(tolerance float64) (*Config, error) {
+	sessionName := t.config.SessionName
+	if present, err := t.hasSession(sessionName); err != nil {
+		return nil, err
+	} else if !present {
+		return nil, fmt.Errorf("session, %s, is not present", sessionName)
+	}
+	windows, err := t.listWindows(sessionName)
+	if err != nil {
+		return nil, fmt.Errorf("cannot list the windows of session, %s: %w", sessionName, err)
+	}
+	tmuxPanes, err := t.listPanes(sessionName)
+	if err != nil {
+		return nil, fmt.Errorf("cannot list the panes of session, %s: %w", sessionName, err)
+	}
+	var panes = make(map[string]*tmuxPane)
+	for _, pane := range tmuxPanes {
+		panes[pane.id] = pane
+	}
+	hostname, _ := os.Hostname()
+
+	var config = &Config{SessionName: sessionName}
+	for _, tmuxWindow := range windows {
+		layout, err := ParseLayout(tmuxWindow.layout)
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse the layout of window, %s: %w", tmuxWindow.name, err)
+		}
+		window := &Window{Name: tmuxWindow.name}
+		var usedNames = make(map[string]bool)
+		for _, leaf := range layout.leaves() {
+			pane, ok := panes[leaf.PaneID]
+			if !ok {
+				return nil, fmt.Errorf("cannot find pane, %s, of window, %s", leaf.PaneID, window.Name)
+			}
+			leaf.PaneName = frozenPaneName(pane, hostname, usedNames)
+			command := &Command{Name: leaf.PaneName, WorkingDirectory: pane.currentPath}
+			if !shells[pane.command] {
+				command.CommandText = pane.command
+			}
+			window.Commands = append(window.Commands, command)
+		}
+		grid, err := layout.AsGrid(tolerance)
+		if err != nil {
+			return nil, fmt.Errorf("cannot convert the layout of window, %s, into a grid: %w", window.Name, err)
+		}
+		window.Grid = FormatGrid(grid)
+		if err = window.Parse(); err != nil {
+			log.Debug().Str("grid", window.Grid).Msgf("the layout of window, %s, cannot be represented", window.Name)
+			return nil, fmt.Errorf("the layout of window, %s, cannot be represented by a grid: %w", window.Name, err)
+		}
+		config.Windows = append(config.Windows, window)
+	}
+	return config, nil
+}
+
+// frozenPaneName names the pane after the name given by chaakoo or its title, the title is ignored if it is same as the
+// hostname which is the default title set by TMUX. The current command is used when the title is not usable and a
+// number is added to the names that are already used in the window.
+func frozenPaneName(pane *tmuxPane, hostname string, usedNames map[string]bool) string {
+	name := pane.name
+	if len(name) == 0 {
+		name = invalidPaneNameChars.ReplaceAllString(strings.TrimSpace(pane.title), "-")
+	}
+	if len(name) == 0 || name == hostname || strings.HasPrefix(hostname, name+".") {
+		name = filepath.Base(pane.command)
+	}
+	if len(name) == 0 || name == "." {
+		name = "pane"
+	}
+	uniqueName := name
+	for i := 2; usedNames[uniqueName]; i++ {
+		uniqueName = name + strconv.Itoa(i)
+	}
+	usedNames[uniqueName] = true
+	return uniqueName
+}
@@ -0,0 +1,62 @@
+package chaakoo
+
+import (
+	"errors"
+	"github.com/golang/mock/gomock"
+	"github.com/pallavJha/chaakoo/mocks"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v2"
+	"strings"
+	"testing"
+)
+
+type FreezeTestCase struct {
+	ID          int
+	Error       string
+	SessionName string
+	Config      string
+	Commands    []*struct {
+		Name   string
+		Args   string
+		Stdout string
+		Stderr string
+		Err    string
+	}
+}
+
+func (c TmuxWrapperTestSuite) testTmuxWrapperFreeze(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var testCases []FreezeTestCase
+	if err := viper.UnmarshalKey("freezes", &testCases); err != nil {
+		t.Log("unable to read from the config", err)
+		t.Fail()
+	}
+	for _, testCase := range testCases {
+		t.Log("testing, id", testCase.ID)
+		wrapper := NewTmuxWrapper(&Config{SessionName: testCase.SessionName}, nil)
+		mockCmdExecutor := mocks.NewMockICommandExecutor(ctrl)
+		wrapper.executor = mockCmdExecutor
+		for _, command := range testCase.Commands {
+			var errorToReturn error
+			if len(command.Err) > 0 {
+				errorToReturn = errors.New(command.Err)
+			}
+			mockCmdExecutor.EXPECT().Execute(command.Name, strings.Split(strings.TrimSpace(command.Args), " ")).Return(
+				command.Stdout, command.Stderr, 0, errorToReturn,
+			)
+		}
+
+		config, err := wrapper.Freeze(0.02)
+		if len(testCase.Error) > 0 {
+			require.EqualError(t, err, testCase.Error)
+			continue
+		}
+		require.NoError(t, err)
+		content, err := yaml.Marshal(config)
+		require.NoError(t, err)
+		require.Equal(t, testCase.Config, string(content))
+	}
+}
@@ -0,0 +1,117 @@
+package chaakoo
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+)
+
+var errNotInTmux = errors.New("chaakoo is not running inside TMUX")
+
+// tmuxClient is the pane, and its window and session, in which chaakoo is running
+type tmuxClient struct {
+	sessionName  string
+	windowID     string
+	paneID       string
+	windowWidth  int
+	windowHeight int
+	windowLayout string
+}
+
+// applyHere creates the windows in the TMUX session in which chaakoo is running, the windows are added after the
+// windows that are already present in the session. If the config asks for the current window then the grid of the first
+// window is applied on the current window by splitting the current pane, see TmuxWrapper.splitPane.
+// If an error occurs then the windows and panes created by chaakoo are killed, unless the config asks to keep them.
+func (t *TmuxWrapper) applyHere() error {
+	client, err := t.currentClient()
+	if err != nil {
+		return fmt.Errorf("cannot find the current TMUX session: %w", err)
+	}
+	log.Debug().Msgf("creating the windows in the current session, %s", client.sessionName)
+	t.config.SessionName = client.sessionName
+	// new windows get the size of the current window
+	t.dimension = NewDimension(client.windowWidth, client.windowHeight)
+	// the env of the session is set before the windows are created so that their panes get it
+	if err = t.setEnvironment(client.sessionName, t.config.Env); err != nil {
+		return fmt.Errorf("cannot set the env of the current session, %s: %w", client.sessionName, err)
+	}
+	if err = t.applyWindowsHere(client); err != nil {
+		t.discard()
+		return err
+	}
+	return nil
+}
+
+func (t *TmuxWrapper) applyWindowsHere(client *tmuxClient) error {
+	windows := t.config.Windows
+	if t.config.CurrentWindow {
+		paneNames, err := t.splitPane(windows[0], client)
+		if err != nil {
+			return err
+		}
+		if err = t.handleRunCommands(windows[0], paneNames); err != nil {
+			return err
+		}
+		windows = windows[1:]
+	}
+	for _, window := range windows {
+		res, err := t.newWindow(client.sessionName, window.Name, t.workingDirectories(window)[window.FirstPane.Name],
+			t.paneEnvironment(window, window.FirstPane.Name))
+		if err != nil {
+			return fmt.Errorf("cannot create the window, %s: %w", window.Name, err)
+		}
+		paneNames, err := t.preparePanes(window, res)
+		if err != nil {
+			return err
+		}
+		if err = t.handleRunCommands(window, paneNames); err != nil {
+			return err
+		}
+	}
+	return t.runCommandGraph()
+}
+
+// currentClient finds the pane in which chaakoo is running using the TMUX_PANE environment variable, if it is not
+// present then TMUX finds the current pane
+func (t *TmuxWrapper) currentClient() (*tmuxClient, error) {
+	if len(os.Getenv("TMUX")) == 0 {
+		return nil, errNotInTmux
+	}
+	// tmux display-message -p -t %3 "#{session_name}|:|#{window_id}|:|#{pane_id}|:|..."
+	var args = []string{
+		"display-message",
+		"-p",
+	}
+	if paneID := os.Getenv("TMUX_PANE"); len(paneID) > 0 {
+		args = append(args, "-t", paneID)
+	}
+	args = append(args, strings.Join([]string{"#{session_name}", "#{window_id}", "#{pane_id}", "#{window_width}",
+		"#{window_height}", "#{window_layout}"}, fieldSeparator))
+	stdout, stderr, _, err := t.executor.Execute(CommandName, args...)
+	if err != nil {
+		return nil, NewTmuxError(stdout, stderr, err)
+	}
+	fields := strings.Split(strings.TrimRight(stdout, "\n"), fieldSeparator)
+	if len(fields) != 6 {
+		log.Debug().Str("output", stdout).Msg("invalid output from display-message sub command")
+		return nil, NewTmuxError(stdout, "", errors.New("cannot parse the current pane from the display-message output"))
+	}
+	width, widthErr := strconv.Atoi(fields[3])
+	height, heightErr := strconv.Atoi(fields[4])
+	if widthErr != nil || heightErr != nil {
+		log.Debug().Str("output", stdout).Msg("invalid window size in the output of display-message sub command")
+		return nil, NewTmuxError(stdout, "", errors.New("cannot parse the size of the current window from the display-message output"))
+	}
+	return &tmuxClient{
+		sessionName:  fields[0],
+		windowID:     fields[1],
+		paneID:       fields[2],
+		windowWidth:  width,
+		windowHeight: height,
+		windowLayout: fields[5],
+	}, nil
+}
@@ -0,0 +1,101 @@
+package chaakoo
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+)
+
+// stages of the hooks, see Hooks
+const (
+	BeforeStartHook = "before_start"
+	AfterStartHook  = "after_start"
+	BeforeStopHook  = "before_stop"
+	AfterStopHook   = "after_stop"
+)
+
+// Hooks are the commands that chaakoo runs on the host, outside TMUX, with hooks in the config. A hook is run with
+// sh -c in the Directory of the config, its output is shown on the terminal and a hook that exits with a non-zero
+// status is an error, the rest of the hooks of the same stage are not run.
+// 	- before_start, before the session is created, an error stops chaakoo before any pane is created
+// 	- after_start, after the session is created and the commands are sent
+// 	- before_stop, before the session is stopped and killed, like with --replace
+// 	- after_stop, after the session is killed
+type Hooks struct {
+	BeforeStart []string `mapstructure:"before_start" yaml:"before_start,omitempty"`
+	AfterStart  []string `mapstructure:"after_start" yaml:"after_start,omitempty"`
+	BeforeStop  []string `mapstructure:"before_stop" yaml:"before_stop,omitempty"`
+	AfterStop   []string `mapstructure:"after_stop" yaml:"after_stop,omitempty"`
+}
+
+// commands returns the hooks of the stage, the hooks can be nil
+func (h *Hooks) commands(stage string) []string {
+	if h == nil {
+		return nil
+	}
+	switch stage {
+	case BeforeStartHook:
+		return h.BeforeStart
+	case AfterStartHook:
+		return h.AfterStart
+	case BeforeStopHook:
+		return h.BeforeStop
+	default:
+		return h.AfterStop
+	}
+}
+
+// merge replaces the hooks of the stages that are present in the override
+func (h *Hooks) merge(override *Hooks) *Hooks {
+	if h == nil || override == nil {
+		if override != nil {
+			return override
+		}
+		return h
+	}
+	var merged = *h
+	if len(override.BeforeStart) > 0 {
+		merged.BeforeStart = override.BeforeStart
+	}
+	if len(override.AfterStart) > 0 {
+		merged.AfterStart = override.AfterStart
+	}
+	if len(override.BeforeStop) > 0 {
+		merged.BeforeStop = override.BeforeStop
+	}
+	if len(override.AfterStop) > 0 {
+		merged.AfterStop = override.AfterStop
+	}
+	return &merged
+}
+
+func (i *interpolator) interpolateHooks(hooks *Hooks) {
+	if hooks == nil {
+		return
+	}
+	for _, commands := range [][]string{hooks.BeforeStart, hooks.AfterStart, hooks.BeforeStop, hooks.AfterStop} {
+		for j := range commands {
+			commands[j] = i.interpolate(commands[j])
+		}
+	}
+}
+
+// RunHooks runs the hooks of the stage one after the other on the host
+func (t *TmuxWrapper) RunHooks(stage string) error {
+	for _, command := range t.config.Hooks.commands(stage) {
+		if len(strings.TrimSpace(command)) == 0 {
+			continue
+		}
+		log.Info().Msgf("running the %s hook: %s", stage, command)
+		script := command
+		if len(t.config.Directory) > 0 {
+			script = "cd " + quoteShell(t.config.Directory) + " && " + command
+		}
+		// sh -c 'cd /code/shop && docker compose up -d'
+		if err := t.executor.ExecuteInTerminal("sh", "-c", script); err != nil {
+			return fmt.Errorf("%s hook, %s, failed: %w", stage, command, err)
+		}
+	}
+	return nil
+}
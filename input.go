@@ -0,0 +1,190 @@
+package chaakoo
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	"golang.org/x/term"
+)
+
+// maxPromptAttempts is the number of times an input is prompted before chaakoo gives up on an invalid value
+const maxPromptAttempts = 3
+
+// Input is a variable of the config whose value is asked when chaakoo starts, see Config.ResolveInputs
+type Input struct {
+	Name    string `mapstructure:"name" yaml:"name"`
+	Prompt  string `mapstructure:"prompt" yaml:"prompt,omitempty"`
+	Default string `mapstructure:"default" yaml:"default,omitempty"`
+	// Values are the allowed values of the input
+	Values []string `mapstructure:"values" yaml:"values,omitempty"`
+	// Pattern is a regular expression that the whole value must match
+	Pattern string `mapstructure:"pattern" yaml:"pattern,omitempty"`
+	// Secret inputs are not echoed on the terminal
+	Secret bool `mapstructure:"secret" yaml:"secret,omitempty"`
+}
+
+// Prompter asks the value of an input
+type Prompter interface {
+	Prompt(input *Input) (string, error)
+}
+
+// ResolveInputs adds the values of the inputs to the values, an input that is already present in the values, like
+// with the --set flags, is only validated. The other inputs are asked with the prompter and an empty answer takes the
+// default value. If the prompter is nil, like when chaakoo is not running in a terminal, then the inputs take their
+// default values and an input without a default value is an error. The inputs are only resolved for the config, a
+// session with inputs is an error.
+func (c *Config) ResolveInputs(values map[string]string, prompter Prompter) error {
+	for _, session := range c.Sessions {
+		if len(session.Inputs) > 0 {
+			return fmt.Errorf("session, %s, cannot have inputs, they can be written at the top of the config", session.SessionName)
+		}
+	}
+	for _, input := range c.Inputs {
+		if err := input.validate(); err != nil {
+			return err
+		}
+	}
+	for _, input := range c.Inputs {
+		if value, ok := values[input.Name]; ok {
+			if err := input.check(value); err != nil {
+				return err
+			}
+			continue
+		}
+		if prompter == nil {
+			if len(input.Default) == 0 {
+				return fmt.Errorf("input, %s, has no default value and it cannot be prompted", input.Name)
+			}
+			if err := input.check(input.Default); err != nil {
+				return err
+			}
+			values[input.Name] = input.Default
+			continue
+		}
+		value, err := input.ask(prompter)
+		if err != nil {
+			return err
+		}
+		values[input.Name] = value
+	}
+	return nil
+}
+
+func (i *Input) ask(prompter Prompter) (string, error) {
+	var err error
+	for attempt := 0; attempt < maxPromptAttempts; attempt++ {
+		var value string
+		value, err = prompter.Prompt(i)
+		if err != nil {
+			return "", fmt.Errorf("cannot prompt input, %s: %w", i.Name, err)
+		}
+		if len(value) == 0 {
+			value = i.Default
+		}
+		if err = i.check(value); err == nil {
+			return value, nil
+		}
+		log.Warn().Err(err).Msgf("invalid value for input, %s", i.Name)
+	}
+	return "", err
+}
+
+func (i *Input) validate() error {
+	if len(i.Name) == 0 {
+		return errors.New("input name is required")
+	}
+	if !environmentVariableName.MatchString(i.Name) {
+		return fmt.Errorf("invalid input name, %s", i.Name)
+	}
+	if _, err := regexp.Compile("^(?:" + i.Pattern + ")$"); err != nil {
+		return fmt.Errorf("invalid pattern for input, %s: %w", i.Name, err)
+	}
+	return nil
+}
+
+// check checks the value against the allowed values and the pattern of the input
+func (i *Input) check(value string) error {
+	if len(value) == 0 {
+		return fmt.Errorf("value of input, %s, is empty", i.Name)
+	}
+	if len(i.Values) > 0 {
+		var allowed bool
+		for _, allowedValue := range i.Values {
+			if value == allowedValue {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return fmt.Errorf("value of input, %s, must be one of %s", i.Name, strings.Join(i.Values, ", "))
+		}
+	}
+	if len(i.Pattern) > 0 && !regexp.MustCompile("^(?:"+i.Pattern+")$").MatchString(value) {
+		return fmt.Errorf("value of input, %s, does not match %s", i.Name, i.Pattern)
+	}
+	return nil
+}
+
+// TerminalPrompter prompts the inputs on the terminal. The answers are read from the stdin without a buffer, so that
+// the secret answers, which are read by term.ReadPassword from the same file, never miss the bytes of a buffer.
+type TerminalPrompter struct {
+	in  *os.File
+	out io.Writer
+}
+
+// NewTerminalPrompter returns a prompter that reads from the stdin and writes to the stderr
+func NewTerminalPrompter() *TerminalPrompter {
+	return &TerminalPrompter{
+		in:  os.Stdin,
+		out: os.Stderr,
+	}
+}
+
+// Prompt shows the prompt of the input with its default value and its allowed values, and reads a line
+// The secret inputs are read without echo and their default values are not shown.
+func (p *TerminalPrompter) Prompt(input *Input) (string, error) {
+	prompt := input.Prompt
+	if len(prompt) == 0 {
+		prompt = input.Name
+	}
+	if len(input.Values) > 0 {
+		prompt += " (" + strings.Join(input.Values, "/") + ")"
+	}
+	if len(input.Default) > 0 && !input.Secret {
+		prompt += " [" + input.Default + "]"
+	}
+	fmt.Fprint(p.out, prompt+": ")
+	if input.Secret {
+		value, err := term.ReadPassword(int(p.in.Fd()))
+		fmt.Fprintln(p.out)
+		return strings.TrimSpace(string(value)), err
+	}
+	value, err := readLine(p.in)
+	if err != nil && (err != io.EOF || len(value) == 0) {
+		return "", err
+	}
+	return strings.TrimSpace(value), nil
+}
+
+// readLine reads one byte at a time until the end of the line, so that nothing after the line is read from the reader
+func readLine(reader io.Reader) (string, error) {
+	var line []byte
+	var b = make([]byte, 1)
+	for {
+		n, err := reader.Read(b)
+		if n > 0 {
+			if b[0] == '\n' {
+				return string(line), nil
+			}
+			line = append(line, b[0])
+		}
+		if err != nil {
+			return string(line), err
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package chaakoo
+
+import (
+	"bytes"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/require"
+	"io"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+type InputTestCase struct {
+	ID          int
+	Inputs      []*Input
+	Sessions    []*Config
+	Set         map[string]string // values of the --set flags
+	Interactive bool
+	Answers     []string
+	Prompts     []string // names of the inputs that are expected to be prompted
+	Values      map[string]string
+	Error       string
+}
+
+type TerminalPrompterTestCase struct {
+	ID      int
+	Inputs  []*Input
+	Stdin   string
+	Answers []string
+	Rest    string // the stdin that is left after the prompts
+	Error   string
+}
+
+// answerPrompter answers the prompts in order and returns io.EOF when it runs out of answers
+type answerPrompter struct {
+	answers []string
+	prompts []string
+}
+
+func (a *answerPrompter) Prompt(input *Input) (string, error) {
+	a.prompts = append(a.prompts, input.Name)
+	if len(a.answers) == 0 {
+		return "", io.EOF
+	}
+	answer := a.answers[0]
+	a.answers = a.answers[1:]
+	return answer, nil
+}
+
+func (i InputSuite) testResolveInputs(t *testing.T) {
+	var testCases []InputTestCase
+	if err := viper.UnmarshalKey("inputs", &testCases); err != nil {
+		t.Log("unable to read from the config", err)
+		t.Fail()
+	}
+	for _, testCase := range testCases {
+		t.Log("Test case", testCase.ID)
+		config := &Config{Inputs: testCase.Inputs, Sessions: testCase.Sessions}
+		var values = make(map[string]string)
+		for name, value := range testCase.Set {
+			values[name] = value
+		}
+		var prompter Prompter
+		answers := &answerPrompter{answers: testCase.Answers}
+		if testCase.Interactive {
+			prompter = answers
+		}
+		err := config.ResolveInputs(values, prompter)
+		require.Equal(t, testCase.Prompts, answers.prompts)
+		if len(testCase.Error) > 0 {
+			require.EqualError(t, err, testCase.Error)
+			continue
+		}
+		require.NoError(t, err)
+		require.Equal(t, testCase.Values, values)
+	}
+}
+
+func (i InputSuite) testTerminalPrompter(t *testing.T) {
+	var testCases []TerminalPrompterTestCase
+	if err := viper.UnmarshalKey("terminal", &testCases); err != nil {
+		t.Log("unable to read from the config", err)
+		t.Fail()
+	}
+	for _, testCase := range testCases {
+		t.Log("Test case", testCase.ID)
+		reader, writer, err := os.Pipe()
+		require.NoError(t, err)
+		_, err = writer.WriteString(testCase.Stdin)
+		require.NoError(t, err)
+		require.NoError(t, writer.Close())
+		prompter := &TerminalPrompter{in: reader, out: &bytes.Buffer{}}
+		var answers []string
+		for _, input := range testCase.Inputs {
+			var answer string
+			answer, err = prompter.Prompt(input)
+			if err != nil {
+				break
+			}
+			answers = append(answers, answer)
+		}
+		if len(testCase.Error) > 0 {
+			require.EqualError(t, err, testCase.Error)
+		} else {
+			require.NoError(t, err)
+		}
+		require.Equal(t, testCase.Answers, answers)
+		rest, err := ioutil.ReadAll(reader)
+		require.NoError(t, err)
+		require.Equal(t, testCase.Rest, string(rest))
+		require.NoError(t, reader.Close())
+	}
+}
@@ -0,0 +1,123 @@
+package chaakoo
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+)
+
+// variablePattern matches ${NAME} and {{ .NAME }}, a $ before them keeps them as they are, like $${NAME}
+var variablePattern = regexp.MustCompile(`\$(\$\{|\{\{)|\$\{([A-Za-z_][A-Za-z0-9_]*)\}|\{\{\s*\.([A-Za-z_][A-Za-z0-9_]*)\s*\}\}`)
+
+// Interpolate replaces the variables, ${NAME} or {{ .NAME }}, in the session name, the hooks, the windows and the
+// commands of the config. The value of a variable is taken from the first of these that has it:
+// 	- values, like the --set flags and the inputs
+// 	- environment variables
+// 	- Vars of the config
+// It returns an error if a variable is not defined. The other forms of the shell variables, like $NAME or
+// ${NAME:-default}, are left for the shell.
+// The sessions of the config are interpolated in the same way, the Vars of a session override the Vars of the config.
+func (c *Config) Interpolate(values map[string]string) error {
+	return c.interpolate(&interpolator{values: values, vars: c.Vars})
+}
+
+func (c *Config) interpolate(interpolator *interpolator) error {
+	c.SessionName = interpolator.interpolate(c.SessionName)
+	c.EnvFile = interpolator.interpolate(c.EnvFile)
+	interpolator.interpolateEnvironment(c.Env)
+	interpolator.interpolateHooks(c.Hooks)
+	if err := interpolator.err(); err != nil {
+		return fmt.Errorf("cannot interpolate the session: %w", err)
+	}
+	for _, window := range c.Windows {
+		window.Name = interpolator.interpolate(window.Name)
+		window.Grid = interpolator.interpolate(window.Grid)
+		window.EnvFile = interpolator.interpolate(window.EnvFile)
+		interpolator.interpolateEnvironment(window.Env)
+		interpolator.interpolateCondition(window.When)
+		if err := interpolator.err(); err != nil {
+			return fmt.Errorf("cannot interpolate window, %s: %w", window.Name, err)
+		}
+		for _, command := range window.Commands {
+			command.Name = interpolator.interpolate(command.Name)
+			command.CommandText = interpolator.interpolate(command.CommandText)
+			command.Keys = interpolator.interpolate(command.Keys)
+			command.Script = interpolator.interpolate(command.Script)
+			command.Stop = interpolator.interpolate(command.Stop)
+			command.WorkingDirectory = interpolator.interpolate(command.WorkingDirectory)
+			command.EnvFile = interpolator.interpolate(command.EnvFile)
+			interpolator.interpolateEnvironment(command.Env)
+			interpolator.interpolateCondition(command.When)
+			for _, condition := range command.WaitFor {
+				condition.Port = interpolator.interpolate(condition.Port)
+				condition.File = interpolator.interpolate(condition.File)
+				condition.HTTP = interpolator.interpolate(condition.HTTP)
+				condition.Output = interpolator.interpolate(condition.Output)
+				condition.Timeout = interpolator.interpolate(condition.Timeout)
+			}
+			for i := range command.DependsOn {
+				command.DependsOn[i] = interpolator.interpolate(command.DependsOn[i])
+			}
+			if err := interpolator.err(); err != nil {
+				return fmt.Errorf("cannot interpolate pane, %s, in window, %s: %w", command.Name, window.Name, err)
+			}
+		}
+	}
+	for _, session := range c.Sessions {
+		sessionInterpolator := *interpolator
+		sessionInterpolator.vars = mergeVariables(interpolator.vars, session.Vars)
+		if err := session.interpolate(&sessionInterpolator); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// interpolator keeps the first variable that is not defined, so a whole section can be interpolated before the error
+// is checked
+type interpolator struct {
+	values          map[string]string
+	vars            map[string]string
+	missingVariable string
+}
+
+func (i *interpolator) interpolate(text string) string {
+	return variablePattern.ReplaceAllStringFunc(text, func(match string) string {
+		groups := variablePattern.FindStringSubmatch(match)
+		if len(groups[1]) > 0 {
+			return groups[1]
+		}
+		name := groups[2] + groups[3]
+		value, ok := i.lookup(name)
+		if !ok && len(i.missingVariable) == 0 {
+			i.missingVariable = name
+		}
+		return value
+	})
+}
+
+func (i *interpolator) interpolateEnvironment(environment map[string]string) {
+	// sorted, so that the error is always about the same variable
+	for _, name := range sortedNames(environment) {
+		environment[name] = i.interpolate(environment[name])
+	}
+}
+
+func (i *interpolator) lookup(name string) (string, bool) {
+	if value, ok := i.values[name]; ok {
+		return value, true
+	}
+	if value, ok := os.LookupEnv(name); ok {
+		return value, true
+	}
+	value, ok := i.vars[name]
+	return value, ok
+}
+
+func (i *interpolator) err() error {
+	if len(i.missingVariable) == 0 {
+		return nil
+	}
+	return fmt.Errorf("variable, %s, is not defined, it can be passed with --set or a shell variable can be "+
+		"written as $${%s}", i.missingVariable, i.missingVariable)
+}
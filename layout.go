@@ -0,0 +1,473 @@
+package chaakoo
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+)
+
+// LayoutType tells how the children of a LayoutCell are arranged
+type LayoutType int
+
+const (
+	// LayoutPane is a leaf cell which holds a pane
+	LayoutPane LayoutType = iota
+	// LayoutLeftRight cell has children placed from left to right, written as {} in the layout string
+	LayoutLeftRight
+	// LayoutTopBottom cell has children placed from top to bottom, written as [] in the layout string
+	LayoutTopBottom
+)
+
+// LayoutCell represents a node in the TMUX layout tree, similar to the layout_cell struct of TMUX.
+// A cell holds either a pane or a list of children. The sizes and offsets are in terminal cells and the children of a
+// cell are separated by a one cell wide border.
+type LayoutCell struct {
+	Type     LayoutType
+	Width    int
+	Height   int
+	XOffset  int
+	YOffset  int
+	PaneName string
+	PaneID   string
+	Children []*LayoutCell
+}
+
+// PrepareLayout converts a 2D grid into a TMUX layout tree for a window of the provided dimension.
+// Every row and column of the grid gets the same share of the window, a pane spanning multiple rows or columns also
+// gets the borders between them.
+func PrepareLayout(grid [][]string, dimension *Dimension) (*LayoutCell, error) {
+	if len(grid) == 0 || len(grid[0]) == 0 {
+		return nil, errors.New("cannot prepare the layout for an empty grid")
+	}
+	if dimension == nil {
+		return nil, errors.New("dimension is required to prepare the layout")
+	}
+	rows, cols := len(grid), len(grid[0])
+	// every column needs at least one cell for itself and one for the border
+	if dimension.Width < 2*cols-1 || dimension.Height < 2*rows-1 {
+		log.Debug().Int("width", dimension.Width).Int("height", dimension.Height).
+			Int("rows", rows).Int("cols", cols).Msg("dimension is too small for the grid")
+		return nil, fmt.Errorf("dimension %dx%d is too small for a grid with %d rows and %d columns",
+			dimension.Width, dimension.Height, rows, cols)
+	}
+	return splitGrid(grid, 0, rows-1, 0, cols-1, dimension)
+}
+
+// splitGrid creates the cell for the grid area between the provided rows and columns
+// The area is first divided at the columns that no pane spans over and then at the rows, if none of these exist
+// then the area must be a single pane.
+func splitGrid(grid [][]string, rowStart, rowEnd, colStart, colEnd int, dimension *Dimension) (*LayoutCell, error) {
+	cell := &LayoutCell{
+		XOffset: cellOffset(colStart, len(grid[0]), dimension.Width),
+		YOffset: cellOffset(rowStart, len(grid), dimension.Height),
+	}
+	cell.Width = cellOffset(colEnd+1, len(grid[0]), dimension.Width) - cell.XOffset - 1
+	cell.Height = cellOffset(rowEnd+1, len(grid), dimension.Height) - cell.YOffset - 1
+
+	if cuts := findColumnCuts(grid, rowStart, rowEnd, colStart, colEnd); len(cuts) > 0 {
+		cell.Type = LayoutLeftRight
+		start := colStart
+		for _, cut := range append(cuts, colEnd+1) {
+			child, err := splitGrid(grid, rowStart, rowEnd, start, cut-1, dimension)
+			if err != nil {
+				return nil, err
+			}
+			cell.Children = append(cell.Children, child)
+			start = cut
+		}
+		return cell, nil
+	}
+	if cuts := findRowCuts(grid, rowStart, rowEnd, colStart, colEnd); len(cuts) > 0 {
+		cell.Type = LayoutTopBottom
+		start := rowStart
+		for _, cut := range append(cuts, rowEnd+1) {
+			child, err := splitGrid(grid, start, cut-1, colStart, colEnd, dimension)
+			if err != nil {
+				return nil, err
+			}
+			cell.Children = append(cell.Children, child)
+			start = cut
+		}
+		return cell, nil
+	}
+
+	paneName := grid[rowStart][colStart]
+	for i := rowStart; i <= rowEnd; i++ {
+		for j := colStart; j <= colEnd; j++ {
+			if grid[i][j] != paneName {
+				return nil, fmt.Errorf("cannot split the grid area containing panes, %s and %s, into a layout",
+					paneName, grid[i][j])
+			}
+		}
+	}
+	cell.Type = LayoutPane
+	cell.PaneName = paneName
+	return cell, nil
+}
+
+// cellOffset returns the first terminal cell of the grid index
+// The last index(count) maps to size + 1 so that the last pane ends at the edge of the window.
+func cellOffset(index, count, size int) int {
+	return index * (size + 1) / count
+}
+
+// findColumnCuts returns the columns, in the area, on which no pane continues from its previous column
+func findColumnCuts(grid [][]string, rowStart, rowEnd, colStart, colEnd int) []int {
+	var cuts []int
+	for j := colStart + 1; j <= colEnd; j++ {
+		cut := true
+		for i := rowStart; i <= rowEnd; i++ {
+			if grid[i][j] == grid[i][j-1] {
+				cut = false
+				break
+			}
+		}
+		if cut {
+			cuts = append(cuts, j)
+		}
+	}
+	return cuts
+}
+
+// findRowCuts returns the rows, in the area, on which no pane continues from its previous row
+func findRowCuts(grid [][]string, rowStart, rowEnd, colStart, colEnd int) []int {
+	var cuts []int
+	for i := rowStart + 1; i <= rowEnd; i++ {
+		cut := true
+		for j := colStart; j <= colEnd; j++ {
+			if grid[i][j] == grid[i-1][j] {
+				cut = false
+				break
+			}
+		}
+		if cut {
+			cuts = append(cuts, i)
+		}
+	}
+	return cuts
+}
+
+// PaneNames returns the names of the panes in the order TMUX assigns the window panes to the layout
+func (l *LayoutCell) PaneNames() []string {
+	var names []string
+	for _, leaf := range l.leaves() {
+		names = append(names, leaf.PaneName)
+	}
+	return names
+}
+
+// Layout returns the TMUX layout string, like, 5f1c,80x24,0,0{40x24,0,0,1,39x24,41,0,2}
+// paneIDs maps the pane names to the TMUX pane IDs
+func (l *LayoutCell) Layout(paneIDs map[string]string) (string, error) {
+	var builder strings.Builder
+	if err := l.write(&builder, paneIDs); err != nil {
+		return "", err
+	}
+	layout := builder.String()
+	return fmt.Sprintf("%04x,%s", layoutChecksum(layout), layout), nil
+}
+
+func (l *LayoutCell) write(builder *strings.Builder, paneIDs map[string]string) error {
+	builder.WriteString(fmt.Sprintf("%dx%d,%d,%d", l.Width, l.Height, l.XOffset, l.YOffset))
+	switch l.Type {
+	case LayoutPane:
+		paneID, ok := paneIDs[l.PaneName]
+		if !ok {
+			return fmt.Errorf("cannot find the pane ID for pane, %s", l.PaneName)
+		}
+		id, err := strconv.Atoi(strings.TrimPrefix(paneID, "%"))
+		if err != nil {
+			return fmt.Errorf("invalid pane ID, %s, for pane, %s: %w", paneID, l.PaneName, err)
+		}
+		builder.WriteString("," + strconv.Itoa(id))
+		return nil
+	case LayoutLeftRight:
+		builder.WriteString("{")
+	case LayoutTopBottom:
+		builder.WriteString("[")
+	}
+	for i, child := range l.Children {
+		if i > 0 {
+			builder.WriteString(",")
+		}
+		if err := child.write(builder, paneIDs); err != nil {
+			return err
+		}
+	}
+	if l.Type == LayoutLeftRight {
+		builder.WriteString("}")
+	} else {
+		builder.WriteString("]")
+	}
+	return nil
+}
+
+// layoutChecksum is same as the layout_checksum function of TMUX
+func layoutChecksum(layout string) uint16 {
+	var checksum uint16
+	for i := 0; i < len(layout); i++ {
+		checksum = (checksum >> 1) + ((checksum & 1) << 15)
+		checksum += uint16(layout[i])
+	}
+	return checksum
+}
+
+// ParseLayout parses the TMUX layout string, like the output of tmux display -p '#{window_layout}', into a layout tree
+// The panes are named pane1, pane2 and so on in the layout order, the TMUX pane ID is kept in PaneID.
+func ParseLayout(layout string) (*LayoutCell, error) {
+	layout = strings.TrimSpace(layout)
+	if len(layout) < 5 || layout[4] != ',' {
+		return nil, fmt.Errorf("invalid layout, %s, it must start with a checksum", layout)
+	}
+	checksum, err := strconv.ParseUint(layout[:4], 16, 16)
+	if err != nil {
+		return nil, fmt.Errorf("invalid checksum in the layout, %s: %w", layout, err)
+	}
+	body := layout[5:]
+	if uint16(checksum) != layoutChecksum(body) {
+		return nil, fmt.Errorf("checksum mismatch for the layout, %s, expected %04x", layout, layoutChecksum(body))
+	}
+	parser := &layoutParser{layout: body}
+	cell, err := parser.parseCell()
+	if err != nil {
+		return nil, err
+	}
+	if parser.position != len(body) {
+		return nil, fmt.Errorf("unexpected character, %c, at %d in the layout", body[parser.position], parser.position)
+	}
+	for i, leaf := range cell.leaves() {
+		leaf.PaneName = "pane" + strconv.Itoa(i+1)
+	}
+	return cell, nil
+}
+
+// layoutParser is a recursive descent parser for the layout string without the checksum
+type layoutParser struct {
+	layout   string
+	position int
+}
+
+func (p *layoutParser) parseCell() (*LayoutCell, error) {
+	var cell = &LayoutCell{}
+	var err error
+	if cell.Width, err = p.parseNumber('x'); err != nil {
+		return nil, err
+	}
+	if cell.Height, err = p.parseNumber(','); err != nil {
+		return nil, err
+	}
+	if cell.XOffset, err = p.parseNumber(','); err != nil {
+		return nil, err
+	}
+	if cell.YOffset, err = p.parseNumber(0); err != nil {
+		return nil, err
+	}
+	if p.position >= len(p.layout) {
+		return nil, errors.New("unexpected end of the layout, pane ID or children are missing")
+	}
+
+	var closing byte
+	switch p.layout[p.position] {
+	case ',':
+		p.position++
+		id, err := p.parseNumber(0)
+		if err != nil {
+			return nil, err
+		}
+		cell.Type = LayoutPane
+		cell.PaneID = "%" + strconv.Itoa(id)
+		return cell, nil
+	case '{':
+		cell.Type = LayoutLeftRight
+		closing = '}'
+	case '[':
+		cell.Type = LayoutTopBottom
+		closing = ']'
+	default:
+		return nil, fmt.Errorf("unexpected character, %c, at %d in the layout", p.layout[p.position], p.position)
+	}
+	p.position++
+	for {
+		child, err := p.parseCell()
+		if err != nil {
+			return nil, err
+		}
+		cell.Children = append(cell.Children, child)
+		if p.position >= len(p.layout) {
+			return nil, fmt.Errorf("unexpected end of the layout, %c is missing", closing)
+		}
+		if p.layout[p.position] == closing {
+			p.position++
+			return cell, nil
+		}
+		if p.layout[p.position] != ',' {
+			return nil, fmt.Errorf("unexpected character, %c, at %d in the layout", p.layout[p.position], p.position)
+		}
+		p.position++
+	}
+}
+
+// parseNumber reads the digits from the current position and skips the separator after them, if it is not 0
+func (p *layoutParser) parseNumber(separator byte) (int, error) {
+	start := p.position
+	for p.position < len(p.layout) && p.layout[p.position] >= '0' && p.layout[p.position] <= '9' {
+		p.position++
+	}
+	if start == p.position {
+		return 0, fmt.Errorf("number expected at %d in the layout", start)
+	}
+	number, _ := strconv.Atoi(p.layout[start:p.position])
+	if separator != 0 {
+		if p.position >= len(p.layout) || p.layout[p.position] != separator {
+			return 0, fmt.Errorf("%c expected at %d in the layout", separator, p.position)
+		}
+		p.position++
+	}
+	return number, nil
+}
+
+// leaves returns the cells holding the panes in the layout order
+func (l *LayoutCell) leaves() []*LayoutCell {
+	if l.Type == LayoutPane {
+		return []*LayoutCell{l}
+	}
+	var leaves []*LayoutCell
+	for _, child := range l.Children {
+		leaves = append(leaves, child.leaves()...)
+	}
+	return leaves
+}
+
+// move moves the cell, and its children, by the provided offsets
+func (l *LayoutCell) move(xOffset, yOffset int) {
+	l.XOffset += xOffset
+	l.YOffset += yOffset
+	for _, child := range l.Children {
+		child.move(xOffset, yOffset)
+	}
+}
+
+// replacePane replaces the cell of the pane, with the provided TMUX pane ID, by the provided cell which must have the
+// same size and offsets. It returns false if the pane is not present in the layout.
+func (l *LayoutCell) replacePane(paneID string, cell *LayoutCell) bool {
+	for i, child := range l.Children {
+		if child.Type == LayoutPane && child.PaneID == paneID {
+			l.Children[i] = cell
+			return true
+		}
+		if child.replacePane(paneID, cell) {
+			return true
+		}
+	}
+	return false
+}
+
+// AsGrid converts the layout into a 2D grid with the least number of rows and columns in which the pane edges are
+// within the tolerance, a fraction of the window size, of their actual position.
+func (l *LayoutCell) AsGrid(tolerance float64) ([][]string, error) {
+	leaves := l.leaves()
+	var xEdges, yEdges []int
+	for _, leaf := range leaves {
+		xEdges = append(xEdges, leaf.XOffset, leaf.XOffset+leaf.Width+1)
+		yEdges = append(yEdges, leaf.YOffset, leaf.YOffset+leaf.Height+1)
+	}
+	cols, err := gridResolution(xEdges, leaves, l.Width, tolerance, func(c *LayoutCell) (int, int) {
+		return c.XOffset, c.XOffset + c.Width + 1
+	})
+	if err != nil {
+		return nil, err
+	}
+	rows, err := gridResolution(yEdges, leaves, l.Height, tolerance, func(c *LayoutCell) (int, int) {
+		return c.YOffset, c.YOffset + c.Height + 1
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var grid = make([][]string, rows)
+	for i := range grid {
+		grid[i] = make([]string, cols)
+	}
+	for _, leaf := range leaves {
+		rowStart, rowEnd := gridIndex(leaf.YOffset, rows, l.Height), gridIndex(leaf.YOffset+leaf.Height+1, rows, l.Height)
+		colStart, colEnd := gridIndex(leaf.XOffset, cols, l.Width), gridIndex(leaf.XOffset+leaf.Width+1, cols, l.Width)
+		for i := rowStart; i < rowEnd; i++ {
+			for j := colStart; j < colEnd; j++ {
+				grid[i][j] = leaf.PaneName
+			}
+		}
+	}
+	for i := range grid {
+		for j := range grid[i] {
+			if len(grid[i][j]) == 0 {
+				return nil, fmt.Errorf("no pane found at index %d, %d of the grid", i, j)
+			}
+		}
+	}
+	return grid, nil
+}
+
+// gridResolution finds the least count of rows or columns for which every edge stays within the tolerance and every
+// pane gets at least one row or column
+func gridResolution(edges []int, leaves []*LayoutCell, size int, tolerance float64,
+	span func(*LayoutCell) (int, int)) (int, error) {
+	maxDistance := int(tolerance * float64(size+1))
+	if maxDistance < 1 {
+		maxDistance = 1
+	}
+	for count := 1; count <= size+1; count++ {
+		fits := true
+		for _, edge := range edges {
+			offset := cellOffset(gridIndex(edge, count, size), count, size)
+			if offset-edge > maxDistance || edge-offset > maxDistance {
+				fits = false
+				break
+			}
+		}
+		for i := 0; fits && i < len(leaves); i++ {
+			start, end := span(leaves[i])
+			if gridIndex(start, count, size) >= gridIndex(end, count, size) {
+				fits = false
+			}
+		}
+		if fits {
+			return count, nil
+		}
+	}
+	return 0, fmt.Errorf("cannot fit the layout of size %d into a grid", size)
+}
+
+// gridIndex is the nearest grid index for the terminal cell, it is the inverse of cellOffset
+func gridIndex(offset, count, size int) int {
+	return (2*offset*count + size + 1) / (2 * (size + 1))
+}
+
+// FormatGrid converts the 2D grid into the text used in the config, the columns are aligned with spaces
+func FormatGrid(grid [][]string) string {
+	if len(grid) == 0 {
+		return ""
+	}
+	var widths = make([]int, len(grid[0]))
+	for _, row := range grid {
+		for j, cell := range row {
+			if len(cell) > widths[j] {
+				widths[j] = len(cell)
+			}
+		}
+	}
+	var builder strings.Builder
+	for _, row := range grid {
+		var line strings.Builder
+		for j, cell := range row {
+			if j > 0 {
+				line.WriteString(" ")
+			}
+			line.WriteString(cell + strings.Repeat(" ", widths[j]-len(cell)))
+		}
+		builder.WriteString(strings.TrimRight(line.String(), " ") + "\n")
+	}
+	return builder.String()
+}
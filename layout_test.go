@@ -0,0 +1,75 @@
+package chaakoo
+
+import (
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/require"
+	"strconv"
+	"testing"
+)
+
+type LayoutTestCase struct {
+	ID        int
+	Grid      string
+	Dimension *Dimension
+	Layout    string
+	Error     string
+}
+
+func (l LayoutSuite) testPrepareLayout(t *testing.T) {
+	var layoutTestCases []LayoutTestCase
+	if err := viper.UnmarshalKey("layouts", &layoutTestCases); err != nil {
+		t.Log("unable to read from the config", err)
+		t.Fail()
+	}
+	for _, testCase := range layoutTestCases {
+		t.Log("Test case", testCase.ID)
+		grid, err := PrepareGrid(testCase.Grid)
+		require.NoError(t, err)
+		layout, err := PrepareLayout(grid, testCase.Dimension)
+		if len(testCase.Error) > 0 {
+			require.EqualError(t, err, testCase.Error)
+			continue
+		}
+		require.NoError(t, err)
+		// pane IDs are assigned in the layout order
+		var paneIDs = make(map[string]string)
+		for i, paneName := range layout.PaneNames() {
+			paneIDs[paneName] = "%" + strconv.Itoa(i)
+		}
+		layoutString, err := layout.Layout(paneIDs)
+		require.NoError(t, err)
+		require.Equal(t, testCase.Layout, layoutString)
+	}
+}
+
+type ImportLayoutTestCase struct {
+	ID         int
+	Layout     string
+	Tolerance  float64
+	GridActual [][]string
+	Error      string
+}
+
+func (l LayoutSuite) testParseLayout(t *testing.T) {
+	var importTestCases []ImportLayoutTestCase
+	if err := viper.UnmarshalKey("imports", &importTestCases); err != nil {
+		t.Log("unable to read from the config", err)
+		t.Fail()
+	}
+	for _, testCase := range importTestCases {
+		t.Log("Test case", testCase.ID)
+		layout, err := ParseLayout(testCase.Layout)
+		if err == nil {
+			var grid [][]string
+			grid, err = layout.AsGrid(testCase.Tolerance)
+			if err == nil {
+				require.Equal(t, testCase.GridActual, grid)
+			}
+		}
+		if len(testCase.Error) > 0 {
+			require.EqualError(t, err, testCase.Error)
+		} else {
+			require.NoError(t, err)
+		}
+	}
+}
@@ -54,3 +54,22 @@ func (mr *MockICommandExecutorMockRecorder) Execute(name interface{}, args ...in
 	varargs := append([]interface{}{name}, args...)
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Execute", reflect.TypeOf((*MockICommandExecutor)(nil).Execute), varargs...)
 }
+
+// ExecuteInTerminal mocks base method.
+func (m *MockICommandExecutor) ExecuteInTerminal(name string, args ...string) error {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{name}
+	for _, a := range args {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "ExecuteInTerminal", varargs...)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ExecuteInTerminal indicates an expected call of ExecuteInTerminal.
+func (mr *MockICommandExecutorMockRecorder) ExecuteInTerminal(name interface{}, args ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{name}, args...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExecuteInTerminal", reflect.TypeOf((*MockICommandExecutor)(nil).ExecuteInTerminal), varargs...)
+}
@@ -0,0 +1,66 @@
+package chaakoo
+
+import (
+	"fmt"
+)
+
+// Profile alters the config when it is selected, like a debug profile that changes the command of a pane and adds a
+// window for the logs, see Config.ApplyProfile
+type Profile struct {
+	Name string            `mapstructure:"name" yaml:"name"`
+	Env  map[string]string `mapstructure:"env" yaml:"env,omitempty"`
+	Vars map[string]string `mapstructure:"vars" yaml:"vars,omitempty"`
+	// Windows are merged into the windows of the config, see Config.merge
+	Windows []*Window `mapstructure:"windows" yaml:"windows,omitempty"`
+	// Disable are the names of the windows that are removed from the config
+	Disable []string `mapstructure:"disable" yaml:"disable,omitempty"`
+}
+
+// ApplyProfile merges the env, the vars and the windows of the profile into the config, see Config.merge, and then
+// removes the windows that the profile disables. The profiles with the same name in the sessions of the config are
+// applied on the sessions. It returns an error if neither the config nor its sessions have the profile.
+// The profile is applied before the config is interpolated, so the windows are disabled by the names in the config.
+func (c *Config) ApplyProfile(name string) error {
+	applied, err := c.applyProfile(name)
+	if err != nil {
+		return err
+	}
+	if !applied {
+		return fmt.Errorf("profile, %s, is not present in the config", name)
+	}
+	return nil
+}
+
+func (c *Config) applyProfile(name string) (bool, error) {
+	var applied bool
+	for _, profile := range c.Profiles {
+		if profile.Name != name {
+			continue
+		}
+		c.merge(&Config{Env: profile.Env, Vars: profile.Vars, Windows: profile.Windows})
+		for _, windowName := range profile.Disable {
+			var disabled bool
+			for i, window := range c.Windows {
+				if window.Name == windowName {
+					c.Windows = append(c.Windows[:i], c.Windows[i+1:]...)
+					disabled = true
+					break
+				}
+			}
+			if !disabled {
+				return false, fmt.Errorf("profile, %s, disables window, %s, which is not present in the config",
+					name, windowName)
+			}
+		}
+		applied = true
+		break
+	}
+	for _, session := range c.Sessions {
+		sessionApplied, err := session.applyProfile(name)
+		if err != nil {
+			return false, fmt.Errorf("cannot apply the profile on session, %s: %w", session.SessionName, err)
+		}
+		applied = applied || sessionApplied
+	}
+	return applied, nil
+}
@@ -0,0 +1,47 @@
+package chaakoo
+
+import (
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v2"
+	"testing"
+)
+
+type ProfileTestCase struct {
+	ID       int
+	Config   string // config as yaml
+	Profiles []string
+	Expected string // expected config without the profiles as yaml
+	Error    string
+}
+
+func (s ProfileSuite) testApplyProfiles(t *testing.T) {
+	var testCases []ProfileTestCase
+	if err := viper.UnmarshalKey("profiles", &testCases); err != nil {
+		t.Log("unable to read from the config", err)
+		t.Fail()
+	}
+	for _, testCase := range testCases {
+		t.Log("Test case", testCase.ID)
+		var config Config
+		require.NoError(t, yaml.Unmarshal([]byte(testCase.Config), &config))
+		var err error
+		for _, profile := range testCase.Profiles {
+			if err = config.ApplyProfile(profile); err != nil {
+				break
+			}
+		}
+		if len(testCase.Error) > 0 {
+			require.EqualError(t, err, testCase.Error)
+			continue
+		}
+		require.NoError(t, err)
+		config.Profiles = nil
+		for _, session := range config.Sessions {
+			session.Profiles = nil
+		}
+		content, err := yaml.Marshal(config)
+		require.NoError(t, err)
+		require.Equal(t, testCase.Expected, string(content))
+	}
+}
@@ -0,0 +1,41 @@
+package chaakoo
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// ScriptWriter writes the script of a pane into a file and returns the path of the file
+type ScriptWriter func(script string) (string, error)
+
+// writeScript writes the script into a temporary file that can only be read by the current user
+func writeScript(script string) (string, error) {
+	file, err := ioutil.TempFile("", "chaakoo-*.sh")
+	if err != nil {
+		return "", fmt.Errorf("cannot create the script file: %w", err)
+	}
+	defer file.Close()
+	if _, err = file.WriteString(script); err != nil {
+		os.Remove(file.Name())
+		return "", fmt.Errorf("cannot write the script file, %s: %w", file.Name(), err)
+	}
+	return file.Name(), nil
+}
+
+// noopScriptWriter is used for dry runs
+func noopScriptWriter(_ string) (string, error) {
+	return filepath.Join(os.TempDir(), "chaakoo-script.sh"), nil
+}
+
+// runScript runs the script in a new process of the pane's shell, so a failure in the script, like with set -e,
+// only stops the script and the pane keeps its shell. The script file is removed after the script ends.
+func (t *TmuxWrapper) runScript(targetPaneID, paneName, script string) error {
+	scriptFile, err := t.scriptWriter(script)
+	if err != nil {
+		return err
+	}
+	scriptFile = quoteShell(scriptFile)
+	return t.sendKeys(targetPaneID, paneName, fmt.Sprintf(`"$SHELL" %s; rm -f %s`, scriptFile, scriptFile))
+}
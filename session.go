@@ -0,0 +1,55 @@
+package chaakoo
+
+import (
+	"errors"
+	"fmt"
+)
+
+// SelectSessions returns the configs of the sessions with the names, or of all the sessions if no name is passed
+// A session gets the env and the env_file of the config, its own env is merged into them, see Config.merge. A config
+// without the sessions is a session itself.
+func (c *Config) SelectSessions(names []string) ([]*Config, error) {
+	if len(c.Sessions) == 0 {
+		for _, name := range names {
+			if name != c.SessionName {
+				return nil, fmt.Errorf("session, %s, is not present in the config", name)
+			}
+		}
+		return []*Config{c}, nil
+	}
+	if len(c.Windows) > 0 {
+		return nil, errors.New("config can have either windows or sessions")
+	}
+	var sessionsByName = make(map[string]*Config)
+	for _, session := range c.Sessions {
+		if _, ok := sessionsByName[session.SessionName]; ok {
+			return nil, fmt.Errorf("session, %s, is present more than once in the config", session.SessionName)
+		}
+		sessionsByName[session.SessionName] = session
+	}
+	var sessions []*Config
+	if len(names) == 0 {
+		for _, session := range c.Sessions {
+			sessions = append(sessions, c.sessionConfig(session))
+		}
+		return sessions, nil
+	}
+	for _, name := range names {
+		session, ok := sessionsByName[name]
+		if !ok {
+			return nil, fmt.Errorf("session, %s, is not present in the config", name)
+		}
+		sessions = append(sessions, c.sessionConfig(session))
+	}
+	return sessions, nil
+}
+
+func (c *Config) sessionConfig(session *Config) *Config {
+	config := &Config{
+		Env:       mergeVariables(nil, c.Env),
+		EnvFile:   c.EnvFile,
+		Directory: c.Directory,
+	}
+	config.merge(session)
+	return config
+}
@@ -0,0 +1,43 @@
+package chaakoo
+
+import (
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v2"
+	"testing"
+)
+
+type SessionTestCase struct {
+	ID       int
+	Config   string // config as yaml
+	Names    []string
+	Set      map[string]string // values of the --set flags
+	Sessions string            // expected sessions as yaml
+	Error    string
+}
+
+func (s SessionSuite) testSelectSessions(t *testing.T) {
+	var testCases []SessionTestCase
+	if err := viper.UnmarshalKey("sessions", &testCases); err != nil {
+		t.Log("unable to read from the config", err)
+		t.Fail()
+	}
+	for _, testCase := range testCases {
+		t.Log("Test case", testCase.ID)
+		var config Config
+		require.NoError(t, yaml.Unmarshal([]byte(testCase.Config), &config))
+		err := config.Interpolate(testCase.Set)
+		var sessions []*Config
+		if err == nil {
+			sessions, err = config.SelectSessions(testCase.Names)
+		}
+		if len(testCase.Error) > 0 {
+			require.EqualError(t, err, testCase.Error)
+			continue
+		}
+		require.NoError(t, err)
+		content, err := yaml.Marshal(sessions)
+		require.NoError(t, err)
+		require.Equal(t, testCase.Sessions, string(content))
+	}
+}
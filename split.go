@@ -0,0 +1,95 @@
+package chaakoo
+
+import (
+	"fmt"
+)
+
+// Split applies the grid of the window on the pane in which chaakoo is running, see TmuxWrapper.splitPane, and then
+// executes the commands of the window.
+// If an error occurs then the panes created by chaakoo are killed, unless the config asks to keep them.
+func (t *TmuxWrapper) Split(window *Window) error {
+	client, err := t.currentClient()
+	if err != nil {
+		return fmt.Errorf("cannot find the current TMUX pane: %w", err)
+	}
+	t.config.SessionName = client.sessionName
+	paneNames, err := t.splitPane(window, client)
+	if err == nil {
+		err = t.handleRunCommands(window, paneNames)
+	}
+	if err == nil {
+		err = t.runCommandGraph()
+	}
+	if err != nil {
+		t.discard()
+		return err
+	}
+	return nil
+}
+
+// splitPane creates the panes of the window by splitting the current pane, the current window can have other panes as
+// well. The layout of the grid is prepared for the size of the current pane and it replaces the current pane in the
+// layout of the current window, so the other panes keep their places and sizes.
+// It returns the pane names of the grid mapped to the TMUX pane IDs.
+func (t *TmuxWrapper) splitPane(window *Window, client *tmuxClient) (map[string]string, error) {
+	windowLayout, err := ParseLayout(client.windowLayout)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse the layout of the current window: %w", err)
+	}
+	var paneNames = make(map[string]string)
+	var paneOrder []string
+	var currentCell *LayoutCell
+	for _, leaf := range windowLayout.leaves() {
+		// the other panes are named after their IDs as they are not present in the grid
+		leaf.PaneName = leaf.PaneID
+		paneNames[leaf.PaneName] = leaf.PaneID
+		paneOrder = append(paneOrder, leaf.PaneID)
+		if leaf.PaneID == client.paneID {
+			currentCell = leaf
+		}
+	}
+	if currentCell == nil {
+		return nil, fmt.Errorf("cannot find pane, %s, in the layout of the current window", client.paneID)
+	}
+	layout, err := PrepareLayout(window.FirstPane.AsGrid(), NewDimension(currentCell.Width, currentCell.Height))
+	if err != nil {
+		return nil, fmt.Errorf("cannot prepare the layout for window, %s: %w", window.Name, err)
+	}
+	layout.move(currentCell.XOffset, currentCell.YOffset)
+	layoutPaneNames := layout.PaneNames()
+	for _, paneName := range layoutPaneNames {
+		if _, ok := paneNames[paneName]; ok {
+			return nil, fmt.Errorf("pane, %s, of window, %s, has the same name as the ID of a pane in the current window",
+				paneName, window.Name)
+		}
+	}
+	if windowLayout == currentCell {
+		windowLayout = layout
+	} else {
+		windowLayout.replacePane(client.paneID, layout)
+	}
+	delete(paneNames, client.paneID)
+	paneNames[window.FirstPane.Name] = client.paneID
+
+	// the current pane is already present, so it cannot be created in its working directory and with its environment
+	if workingDirectory, ok := t.workingDirectories(window)[window.FirstPane.Name]; ok {
+		if err = t.changeDirectory(client.paneID, window.FirstPane.Name, workingDirectory); err != nil {
+			return nil, err
+		}
+	}
+	if err = t.exportEnvironment(client.paneID, window, window.FirstPane.Name); err != nil {
+		return nil, err
+	}
+	if err = t.walkPane(window.FirstPane, paneNames, &paneOrder, window); err != nil {
+		return nil, fmt.Errorf("cannot walk the pane: %w", err)
+	}
+	if err = t.selectLayout(client.windowID, windowLayout, paneNames, paneOrder); err != nil {
+		return nil, fmt.Errorf("cannot apply the layout for window, %s: %w", window.Name, err)
+	}
+	var gridPaneNames = make(map[string]string)
+	for _, paneName := range layoutPaneNames {
+		gridPaneNames[paneName] = paneNames[paneName]
+		t.markPaneOrWarn(paneNames[paneName], paneName)
+	}
+	return gridPaneNames, nil
+}
@@ -0,0 +1,168 @@
+package chaakoo
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// PollInterval is the time between two checks while waiting for the panes
+var PollInterval = 250 * time.Millisecond
+
+// DefaultStopTimeout is the time given to the commands to stop by Down, if the config does not have a StopTimeout
+var DefaultStopTimeout = 10 * time.Second
+
+// keyName matches the TMUX key names, like C-c, M-x or Escape
+var keyName = regexp.MustCompile(`^(([CMS]-)+\S+|Escape|Enter|Tab|BTab|BSpace|Space|Up|Down|Left|Right|Home|End|` +
+	`PageUp|PgUp|PageDown|PgDn|PPage|NPage|IC|DC|F[0-9]+)$`)
+
+// Down stops the session of the config and then kills it:
+// 	- runs the before_stop hooks, see Hooks
+// 	- sends the stop sequence of every pane and waits, until the StopTimeout or the DefaultStopTimeout, for the panes
+// 	to stop, see TmuxWrapper.stopSession
+// 	- kills the session
+// 	- runs the after_stop hooks
+func (t *TmuxWrapper) Down() error {
+	present, err := t.hasSession(t.config.SessionName)
+	if err != nil {
+		return err
+	}
+	if !present {
+		return fmt.Errorf("session, %s, is not present", t.config.SessionName)
+	}
+	if err = t.RunHooks(BeforeStopHook); err != nil {
+		return err
+	}
+	timeout := t.config.StopTimeout
+	if timeout <= 0 {
+		timeout = DefaultStopTimeout
+	}
+	if err = t.stopSession(t.config.SessionName, timeout); err != nil {
+		return err
+	}
+	t.killSession(t.config.SessionName)
+	return t.RunHooks(AfterStopHook)
+}
+
+// stopSession sends the stop sequence of the command of every pane, see TmuxWrapper.sendStop, and then waits, until
+// the timeout, for those panes to come back to their shells, to exit or to be dead. A pane without a stop sequence is
+// interrupted with C-c, unless it is already in its shell.
+func (t *TmuxWrapper) stopSession(sessionName string, timeout time.Duration) error {
+	panes, err := t.listPanes(sessionName)
+	if err != nil {
+		return fmt.Errorf("cannot list the panes of session, %s: %w", sessionName, err)
+	}
+	commands, err := t.paneCommands(sessionName)
+	if err != nil {
+		return err
+	}
+	var stopping = make(map[string]bool)
+	for _, pane := range panes {
+		command := commands[pane.windowID+"\t"+pane.name]
+		if command != nil && len(strings.TrimSpace(command.Stop)) > 0 {
+			if err = t.sendStop(pane.id, command); err != nil {
+				return err
+			}
+			stopping[pane.id] = true
+			continue
+		}
+		if pane.dead || shells[pane.command] {
+			continue
+		}
+		if err = t.sendKey(pane.id, "C-c"); err != nil {
+			return err
+		}
+		stopping[pane.id] = true
+	}
+	if len(stopping) == 0 {
+		return nil
+	}
+	deadline := time.Now().Add(timeout)
+	for {
+		time.Sleep(PollInterval)
+		if panes, err = t.listPanes(sessionName); err != nil {
+			return fmt.Errorf("cannot list the panes of session, %s: %w", sessionName, err)
+		}
+		running := 0
+		for _, pane := range panes {
+			if stopping[pane.id] && !pane.dead && !shells[pane.command] {
+				running++
+			}
+		}
+		if running == 0 {
+			log.Debug().Msgf("commands of session, %s, have stopped", sessionName)
+			return nil
+		}
+		if time.Now().After(deadline) {
+			log.Warn().Msgf("%d panes of session, %s, are still running after %s", running, sessionName, timeout)
+			return nil
+		}
+	}
+}
+
+// paneCommands returns the commands of the config mapped to the window IDs and the pane names of the session
+func (t *TmuxWrapper) paneCommands(sessionName string) (map[string]*Command, error) {
+	var commands = make(map[string]*Command)
+	if !t.hasStopSequences() {
+		return commands, nil
+	}
+	windows, err := t.listWindows(sessionName)
+	if err != nil {
+		return nil, fmt.Errorf("cannot list the windows of session, %s: %w", sessionName, err)
+	}
+	for _, tmuxWindow := range windows {
+		for _, window := range t.config.Windows {
+			if window.Name != tmuxWindow.name {
+				continue
+			}
+			for _, command := range window.Commands {
+				commands[tmuxWindow.id+"\t"+command.Name] = command
+			}
+		}
+	}
+	return commands, nil
+}
+
+// hasStopSequences returns true if a command of the config has a stop sequence
+func (t *TmuxWrapper) hasStopSequences() bool {
+	for _, window := range t.config.Windows {
+		for _, command := range window.Commands {
+			if len(strings.TrimSpace(command.Stop)) > 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// sendStop sends the stop sequence of the command to the pane. A line of the sequence whose words are TMUX key names,
+// like C-c, is sent as the keys and the other lines are typed in the pane and followed by the Enter key, like make stop.
+func (t *TmuxWrapper) sendStop(paneID string, command *Command) error {
+	for _, line := range strings.Split(strings.Trim(command.Stop, "\n"), "\n") {
+		if len(strings.TrimSpace(line)) == 0 {
+			continue
+		}
+		if keys := strings.Fields(line); isKeys(keys) {
+			if err := t.sendKey(paneID, keys...); err != nil {
+				return fmt.Errorf("cannot stop pane, %s: %w", command.Name, err)
+			}
+			continue
+		}
+		if err := t.sendKeys(paneID, command.Name, line); err != nil {
+			return fmt.Errorf("cannot stop pane, %s: %w", command.Name, err)
+		}
+	}
+	return nil
+}
+
+func isKeys(words []string) bool {
+	for _, word := range words {
+		if !keyName.MatchString(word) {
+			return false
+		}
+	}
+	return true
+}
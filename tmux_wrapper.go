@@ -5,8 +5,9 @@ import (
 	"errors"
 	"fmt"
 	"github.com/rs/zerolog/log"
+	"os"
 	"os/exec"
-	"path/filepath"
+	"regexp"
 	"strconv"
 	"strings"
 )
@@ -15,6 +16,13 @@ import (
 // Not hardcoded in the command itself because it can be used in testcases to run a mock command
 var CommandName = "tmux"
 
+// PaneOption is the TMUX user option in which chaakoo keeps the name of the pane
+const PaneOption = "@chaakoo-pane"
+
+// fieldSeparator separates the fields of the -F formats, TMUX prints the tabs of a format as _ so a printable token is
+// used instead
+const fieldSeparator = "|:|"
+
 // TmuxError is returned after the execution of TMUX commands
 type TmuxError struct {
 	stdout, stderr string
@@ -42,11 +50,36 @@ type TmuxCmdResponse struct {
 	PaneID    string
 }
 
+// tmuxWindow is a window of a running session
+type tmuxWindow struct {
+	id     string
+	name   string
+	layout string
+}
+
+// tmuxPane is a pane of a running session
+type tmuxPane struct {
+	windowID    string
+	id          string
+	name        string // name given by chaakoo, kept in the PaneOption
+	width       int
+	height      int
+	currentPath string
+	command     string
+	dead        bool // the process of the pane has exited, it is kept with remain-on-exit
+	title       string
+}
+
 // TmuxWrapper implements the logic to convert the pane and config into TMUX panes and command executions
 type TmuxWrapper struct {
 	config    *Config
 	dimension *Dimension
 	executor  ICommandExecutor
+	created   []string // windows and panes created in a session that was already present, see TmuxWrapper.rollback
+	// scriptWriter writes the scripts of the panes into files, see TmuxWrapper.runScript
+	scriptWriter ScriptWriter
+	// pending are the windows whose commands are sent together when the commands have dependencies
+	pending []*pendingWindow
 }
 
 // NewTmuxWrapper constructs a TmuxWrapper
@@ -57,54 +90,160 @@ func NewTmuxWrapper(config *Config, dimensions *Dimension) *TmuxWrapper {
 	}
 	if config.DryRun {
 		wrapper.executor = NewNOOPExecutor()
+		wrapper.scriptWriter = noopScriptWriter
 	} else {
 		wrapper.executor = NewCommandExecutor()
+		wrapper.scriptWriter = writeScript
 	}
 	return wrapper
 }
 
 // Apply does:
-// 	- checks if the requested session is already present
+// 	- checks if the requested session is already present, if it is and the config allows update then the session is
+// 	updated, see TmuxWrapper.update, and if the config asks to replace it then the session is killed, a session that
+// 	is only going to be attached is left as it is
 // 	- creates a new session for the current config
 // 	- creates windows and panes
+// 	- applies the layout of the grid on the windows
 // 	- executes the command of the provided config
+// If an error occurs after the session has been created then the session is killed, unless the config asks to keep it.
+// If the config asks for the current session then the windows are created in it instead, see TmuxWrapper.applyHere.
+// The hooks of the start are only run when the session is created, replaced or updated, or when the windows are
+// created in the current session, see TmuxWrapper.start.
 func (t *TmuxWrapper) Apply() error {
+	if t.config.Here {
+		return t.start(t.applyHere)
+	}
 	if present, err := t.hasSession(t.config.SessionName); err != nil {
 		return err
+	} else if present && t.config.Replace {
+		log.Info().Msgf("replacing the session, %s", t.config.SessionName)
+		if err = t.RunHooks(BeforeStopHook); err != nil {
+			return err
+		}
+		if t.config.StopTimeout > 0 {
+			if err = t.stopSession(t.config.SessionName, t.config.StopTimeout); err != nil {
+				return err
+			}
+		}
+		t.killSession(t.config.SessionName)
+		if err = t.RunHooks(AfterStopHook); err != nil {
+			return err
+		}
+	} else if present && t.config.Update {
+		log.Debug().Msgf("session, %s, is already present, updating it", t.config.SessionName)
+		return t.start(t.update)
+	} else if present && t.config.Attach {
+		log.Info().Msgf("session, %s, is already present, it will be attached", t.config.SessionName)
+		return nil
 	} else if present {
 		log.Debug().Msgf("session with same name, %s, is already present", t.config.SessionName)
 		return fmt.Errorf("session with same name, %s, is already present", t.config.SessionName)
 	}
-	res, err := t.newSession(t.config.SessionName, t.config.Windows[0].Name, t.dimension)
+	return t.start(t.createSession)
+}
+
+// start runs the before_start hooks, then creates or changes the session and then runs the after_start hooks, see
+// Hooks. A failing before_start hook stops it before anything is created.
+func (t *TmuxWrapper) start(create func() error) error {
+	if err := t.RunHooks(BeforeStartHook); err != nil {
+		return err
+	}
+	if err := create(); err != nil {
+		return err
+	}
+	return t.RunHooks(AfterStartHook)
+}
+
+// createSession creates a new session with the windows of the config, the session is killed if an error occurs after it
+// has been created, unless the config asks to keep it
+func (t *TmuxWrapper) createSession() error {
+	firstWindow := t.config.Windows[0]
+	res, err := t.newSession(t.config.SessionName, firstWindow.Name, t.dimension,
+		t.workingDirectories(firstWindow)[firstWindow.FirstPane.Name], t.config.Env)
 	if err != nil {
 		return fmt.Errorf("cannot create the session: %w", err)
 	}
-	var paneNames = make(map[string]string)
-	paneNames[t.config.Windows[0].FirstPane.Name] = res.PaneID
-	if err = t.walkPane(t.config.Windows[0].FirstPane, paneNames); err != nil {
-		return fmt.Errorf("cannot walk the pane: %w", err)
+	if err = t.applyWindows(res); err != nil {
+		if t.config.KeepOnError {
+			log.Info().Msgf("keeping the session, %s, for inspection", t.config.SessionName)
+		} else {
+			log.Debug().Msgf("error while creating a new session, killing the session(%s)", t.config.SessionName)
+			t.killSession(t.config.SessionName)
+		}
+		return err
+	}
+	return nil
+}
+
+// applyWindows creates the panes of the first window, whose first pane is present in the response, and then the rest
+// of the windows
+func (t *TmuxWrapper) applyWindows(res *TmuxCmdResponse) error {
+	// new-session sets the environment of the session, so the first pane is respawned with its own environment
+	firstWindow := t.config.Windows[0]
+	if environment := t.paneEnvironment(firstWindow, firstWindow.FirstPane.Name); len(environment) > 0 {
+		if err := t.respawnPane(res.PaneID, environment); err != nil {
+			return fmt.Errorf("cannot set the env of pane, %s, in window, %s: %w", firstWindow.FirstPane.Name,
+				firstWindow.Name, err)
+		}
+	}
+	paneNames, err := t.preparePanes(t.config.Windows[0], res)
+	if err != nil {
+		return err
 	}
 	if err = t.handleRunCommands(t.config.Windows[0], paneNames); err != nil {
 		return err
 	}
 	for i := 1; i < len(t.config.Windows); i++ {
-		res, err = t.newWindow(t.config.SessionName, t.config.Windows[i].Name)
+		window := t.config.Windows[i]
+		res, err = t.newWindow(t.config.SessionName, window.Name, t.workingDirectories(window)[window.FirstPane.Name],
+			t.paneEnvironment(window, window.FirstPane.Name))
 		if err != nil {
-			return fmt.Errorf("cannot create the window, %s: %w", t.config.Windows[i].Name, err)
+			return fmt.Errorf("cannot create the window, %s: %w", window.Name, err)
 		}
-		paneNames = make(map[string]string)
-		paneNames[t.config.Windows[i].FirstPane.Name] = res.PaneID
-		if err = t.walkPane(t.config.Windows[i].FirstPane, paneNames); err != nil {
+		paneNames, err = t.preparePanes(window, res)
+		if err != nil {
 			return err
 		}
-		if err = t.handleRunCommands(t.config.Windows[i], paneNames); err != nil {
+		if err = t.handleRunCommands(window, paneNames); err != nil {
 			return err
 		}
 	}
-	return nil
+	return t.runCommandGraph()
 }
 
+// preparePanes creates the panes of the window, whose first pane is present in the response, and then applies the
+// layout prepared from the grid so that every pane gets the exact size.
+// It returns the pane names mapped to the TMUX pane IDs.
+func (t *TmuxWrapper) preparePanes(window *Window, res *TmuxCmdResponse) (map[string]string, error) {
+	// layout must be prepared before the walk as the walk shrinks the panes
+	layout, err := PrepareLayout(window.FirstPane.AsGrid(), t.dimension)
+	if err != nil {
+		return nil, fmt.Errorf("cannot prepare the layout for window, %s: %w", window.Name, err)
+	}
+	var paneNames = make(map[string]string)
+	paneNames[window.FirstPane.Name] = res.PaneID
+	var paneOrder = []string{res.PaneID}
+	if err = t.walkPane(window.FirstPane, paneNames, &paneOrder, window); err != nil {
+		return nil, fmt.Errorf("cannot walk the pane: %w", err)
+	}
+	if err = t.selectLayout(res.WindowID, layout, paneNames, paneOrder); err != nil {
+		return nil, fmt.Errorf("cannot apply the layout for window, %s: %w", window.Name, err)
+	}
+	for _, paneName := range layout.PaneNames() {
+		t.markPaneOrWarn(paneNames[paneName], paneName)
+	}
+	return paneNames, nil
+}
+
+// handleRunCommands executes the commands of the window, it returns the error only with ExitOnError.
+// If the commands have dependencies then the window is kept until every window is created, see
+// TmuxWrapper.runCommandGraph.
 func (t *TmuxWrapper) handleRunCommands(window *Window, paneNames map[string]string) error {
+	if t.config.hasDependencies() {
+		t.pending = append(t.pending, &pendingWindow{window: window, paneNames: paneNames})
+		return nil
+	}
 	if err := t.runCommands(window, paneNames); err != nil {
 		if t.config.ExitOnError {
 			log.Error().Err(err).Msgf("error while executing the commands for windows %s", window.Name)
@@ -121,30 +260,45 @@ func (t *TmuxWrapper) runCommands(window *Window, paneNames map[string]string) e
 		if !ok {
 			continue
 		}
-		wd := strings.TrimSpace(command.WorkingDirectory)
-		if len(wd) > 0 {
-			absPath, err := filepath.Abs(wd)
-			if err != nil {
-				return fmt.Errorf("cannot find the abs path for pane %s: %w", command.Name, err)
-			}
-			if err = t.sendKeys(paneID, command.Name, []string{"cd", absPath}); err != nil {
-				return err
-			}
+		if err := t.runCommand(window, paneID, command); err != nil {
+			return err
 		}
-		if len(command.CommandText) > 0 {
-			commandText := strings.TrimSpace(command.CommandText)
-			commands := strings.Split(commandText, "\n")
-			for _, commandText := range commands {
-				commandText = strings.TrimSpace(commandText)
-				if len(commandText) == 0 {
-					continue
-				}
-				if err := t.sendKeys(paneID, command.Name, strings.Fields(commandText)); err != nil {
-					return fmt.Errorf("cannot execute the commands for pane %s: %w", command.Name, err)
-				}
+	}
+	return nil
+}
+
+// runCommand sends the keys, the command and the script of the pane and then waits for its wait_for conditions
+func (t *TmuxWrapper) runCommand(window *Window, paneID string, command *Command) error {
+	if t.config.ChangeDirectory && len(command.WorkingDirectory) > 0 {
+		if err := t.changeDirectory(paneID, command.Name, command.WorkingDirectory); err != nil {
+			return err
+		}
+	}
+	for _, keys := range strings.Split(strings.TrimSpace(command.Keys), "\n") {
+		if len(strings.TrimSpace(keys)) == 0 {
+			continue
+		}
+		if err := t.sendKey(paneID, strings.Fields(keys)...); err != nil {
+			return fmt.Errorf("cannot send the keys to pane %s: %w", command.Name, err)
+		}
+	}
+	if len(strings.TrimSpace(command.CommandText)) > 0 {
+		// the lines are sent as they are, only the new lines around the block are removed
+		commandText := strings.Trim(command.CommandText, "\n")
+		for _, commandText := range strings.Split(commandText, "\n") {
+			if err := t.sendKeys(paneID, command.Name, commandText); err != nil {
+				return fmt.Errorf("cannot execute the commands for pane %s: %w", command.Name, err)
 			}
 		}
 	}
+	if len(strings.TrimSpace(command.Script)) > 0 {
+		if err := t.runScript(paneID, command.Name, command.Script); err != nil {
+			return fmt.Errorf("cannot run the script for pane %s: %w", command.Name, err)
+		}
+	}
+	if err := t.waitFor(paneID, window.Name, command); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -164,7 +318,12 @@ func (t *TmuxWrapper) runCommands(window *Window, paneNames map[string]string) e
 //	|         |
 //	-----------
 // the bottom pane will be created first and then the left pane will be created from the remaining area
-func (t *TmuxWrapper) walkPane(currentPane *Pane, paneNames map[string]string) error {
+// paneOrder keeps the pane IDs in the same order as TMUX keeps them in the window, it is used to apply the layout.
+// The panes are created in their working directories and with their environments, see TmuxWrapper.workingDirectories
+// and TmuxWrapper.paneEnvironment.
+func (t *TmuxWrapper) walkPane(currentPane *Pane, paneNames map[string]string, paneOrder *[]string,
+	window *Window) error {
+	workingDirectories := t.workingDirectories(window)
 	currentPane.reset()
 	for {
 		var leftPane, bottomPane *Pane
@@ -179,52 +338,60 @@ func (t *TmuxWrapper) walkPane(currentPane *Pane, paneNames map[string]string) e
 		} else if leftPane != nil && bottomPane == nil {
 			currentPane.priorLeftIndex--
 			sizeInPercentage := float64(leftPane.Width()*100) / float64(currentPane.Width())
-			res, err := t.newPane(paneNames[currentPane.Name], int(sizeInPercentage), true)
+			res, err := t.newPane(paneNames[currentPane.Name], int(sizeInPercentage), true,
+				workingDirectories[leftPane.Name], t.paneEnvironment(window, leftPane.Name))
 			if err != nil {
 				return err
 			}
 			paneNames[leftPane.Name] = res.PaneID
+			*paneOrder = insertAfter(*paneOrder, paneNames[currentPane.Name], res.PaneID)
 			currentPane.XEnd = leftPane.XStart - 1
-			err = t.walkPane(leftPane, paneNames)
+			err = t.walkPane(leftPane, paneNames, paneOrder, window)
 			if err != nil {
 				return err
 			}
 		} else if leftPane == nil && bottomPane != nil {
 			currentPane.priorBottomIndex--
 			sizeInPercentage := float64(bottomPane.Height()*100) / float64(currentPane.Height())
-			res, err := t.newPane(paneNames[currentPane.Name], int(sizeInPercentage), false)
+			res, err := t.newPane(paneNames[currentPane.Name], int(sizeInPercentage), false,
+				workingDirectories[bottomPane.Name], t.paneEnvironment(window, bottomPane.Name))
 			if err != nil {
 				return err
 			}
 			paneNames[bottomPane.Name] = res.PaneID
+			*paneOrder = insertAfter(*paneOrder, paneNames[currentPane.Name], res.PaneID)
 			currentPane.YEnd = bottomPane.YStart - 1
-			err = t.walkPane(bottomPane, paneNames)
+			err = t.walkPane(bottomPane, paneNames, paneOrder, window)
 			if err != nil {
 				return err
 			}
 		} else if leftPane.Height() == currentPane.Height() {
 			currentPane.priorLeftIndex--
 			sizeInPercentage := float64(leftPane.Width()*100) / float64(currentPane.Width())
-			res, err := t.newPane(paneNames[currentPane.Name], int(sizeInPercentage), true)
+			res, err := t.newPane(paneNames[currentPane.Name], int(sizeInPercentage), true,
+				workingDirectories[leftPane.Name], t.paneEnvironment(window, leftPane.Name))
 			if err != nil {
 				return err
 			}
 			paneNames[leftPane.Name] = res.PaneID
+			*paneOrder = insertAfter(*paneOrder, paneNames[currentPane.Name], res.PaneID)
 			currentPane.XEnd = leftPane.XStart - 1
-			err = t.walkPane(leftPane, paneNames)
+			err = t.walkPane(leftPane, paneNames, paneOrder, window)
 			if err != nil {
 				return err
 			}
 		} else if bottomPane.Width() == currentPane.Width() {
 			currentPane.priorBottomIndex--
 			sizeInPercentage := float64(bottomPane.Height()*100) / float64(currentPane.Height())
-			res, err := t.newPane(paneNames[currentPane.Name], int(sizeInPercentage), false)
+			res, err := t.newPane(paneNames[currentPane.Name], int(sizeInPercentage), false,
+				workingDirectories[bottomPane.Name], t.paneEnvironment(window, bottomPane.Name))
 			if err != nil {
 				return err
 			}
 			paneNames[bottomPane.Name] = res.PaneID
+			*paneOrder = insertAfter(*paneOrder, paneNames[currentPane.Name], res.PaneID)
 			currentPane.YEnd = bottomPane.YStart - 1
-			err = t.walkPane(bottomPane, paneNames)
+			err = t.walkPane(bottomPane, paneNames, paneOrder, window)
 			if err != nil {
 				return err
 			}
@@ -232,8 +399,53 @@ func (t *TmuxWrapper) walkPane(currentPane *Pane, paneNames map[string]string) e
 	}
 }
 
-func (t *TmuxWrapper) newSession(sessionName, windowName string, dimensions *Dimension) (*TmuxCmdResponse, error) {
-	// tmux new-session -d -s session2 -n vim -x 136 -y 80
+// insertAfter inserts the pane ID right after the target, split-window places the new pane after the split pane
+func insertAfter(paneOrder []string, targetPaneID, paneID string) []string {
+	for i, id := range paneOrder {
+		if id == targetPaneID {
+			paneOrder = append(paneOrder[:i+1], append([]string{paneID}, paneOrder[i+1:]...)...)
+			return paneOrder
+		}
+	}
+	return append(paneOrder, paneID)
+}
+
+// selectLayout applies the layout on the window
+// TMUX assigns the window panes to the layout panes in order, so the pane names are mapped again using the pane order.
+func (t *TmuxWrapper) selectLayout(windowID string, layout *LayoutCell, paneNames map[string]string, paneOrder []string) error {
+	layoutPaneNames := layout.PaneNames()
+	if len(layoutPaneNames) != len(paneOrder) {
+		log.Debug().Strs("layoutPanes", layoutPaneNames).Strs("paneIDs", paneOrder).Msg("pane count mismatch")
+		return fmt.Errorf("layout has %d panes but the window has %d panes", len(layoutPaneNames), len(paneOrder))
+	}
+	if len(paneOrder) < 2 {
+		// nothing to arrange in a window with a single pane
+		return nil
+	}
+	for i, paneName := range layoutPaneNames {
+		paneNames[paneName] = paneOrder[i]
+	}
+	layoutString, err := layout.Layout(paneNames)
+	if err != nil {
+		return err
+	}
+	// tmux select-layout -t @1 5f1c,80x24,0,0{40x24,0,0,1,39x24,41,0,2}
+	var args = []string{
+		"select-layout",
+		"-t",
+		windowID,
+		layoutString,
+	}
+	stdout, stderr, _, err := t.executor.Execute(CommandName, args...)
+	if err != nil {
+		return NewTmuxError(stdout, stderr, err)
+	}
+	return nil
+}
+
+func (t *TmuxWrapper) newSession(sessionName, windowName string, dimensions *Dimension, workingDirectory string,
+	environment map[string]string) (*TmuxCmdResponse, error) {
+	// tmux new-session -d -s session2 -n vim -x 136 -y 80 -c /home/user/code -e PORT=8080
 	var args = []string{
 		"new-session",
 		"-d",
@@ -245,10 +457,10 @@ func (t *TmuxWrapper) newSession(sessionName, windowName string, dimensions *Dim
 		strconv.Itoa(dimensions.Width),
 		"-y",
 		strconv.Itoa(dimensions.Height),
-		"-P",
-		"-F",
-		"#{window_id}--#{pane_id}",
 	}
+	args = appendWorkingDirectory(args, workingDirectory)
+	args = appendEnvironment(args, environment)
+	args = append(args, "-P", "-F", "#{window_id}--#{pane_id}")
 	stdout, stderr, _, err := t.executor.Execute(CommandName, args...)
 	if err != nil {
 		return nil, NewTmuxError(stdout, stderr, err)
@@ -267,8 +479,9 @@ func (t *TmuxWrapper) newSession(sessionName, windowName string, dimensions *Dim
 	}, nil
 }
 
-func (t *TmuxWrapper) newWindow(sessionName, windowName string) (*TmuxCmdResponse, error) {
-	// tmux new-window -t session3 -n vim2  -P -F "#{window_id}--#{pane_id}"
+func (t *TmuxWrapper) newWindow(sessionName, windowName, workingDirectory string,
+	environment map[string]string) (*TmuxCmdResponse, error) {
+	// tmux new-window -t session3 -n vim2 -c /home/user/code -e PORT=8080 -P -F "#{window_id}--#{pane_id}"
 	// @9--%19
 
 	var args = []string{
@@ -277,10 +490,10 @@ func (t *TmuxWrapper) newWindow(sessionName, windowName string) (*TmuxCmdRespons
 		sessionName,
 		"-n",
 		windowName,
-		"-P",
-		"-F",
-		"#{window_id}--#{pane_id}",
 	}
+	args = appendWorkingDirectory(args, workingDirectory)
+	args = appendEnvironment(args, environment)
+	args = append(args, "-P", "-F", "#{window_id}--#{pane_id}")
 	stdout, stderr, _, err := t.executor.Execute(CommandName, args...)
 	if err != nil {
 		return nil, NewTmuxError(stdout, stderr, err)
@@ -292,6 +505,7 @@ func (t *TmuxWrapper) newWindow(sessionName, windowName string) (*TmuxCmdRespons
 		log.Debug().Interface("output", splitOutput).Msg("invalid output from new-window sub command")
 		return nil, NewTmuxError(stdout, "", errors.New("cannot parse the windowID and pane ID from the new-window output"))
 	}
+	t.track(splitOutput[0], splitOutput[0])
 	return &TmuxCmdResponse{
 		SessionID: "",
 		WindowID:  splitOutput[0],
@@ -299,8 +513,9 @@ func (t *TmuxWrapper) newWindow(sessionName, windowName string) (*TmuxCmdRespons
 	}, nil
 }
 
-func (t *TmuxWrapper) newPane(targetPaneID string, sizeInPercentage int, horizontalSplit bool) (*TmuxCmdResponse, error) {
-	// tmux splitw -h -l 10% -t 0 -P -F "#{pane_id}"
+func (t *TmuxWrapper) newPane(targetPaneID string, sizeInPercentage int, horizontalSplit bool, workingDirectory string,
+	environment map[string]string) (*TmuxCmdResponse, error) {
+	// tmux splitw -h -l 10% -t 0 -c /home/user/code -e PORT=8080 -P -F "#{pane_id}"
 	// %10
 
 	var args = []string{
@@ -310,10 +525,10 @@ func (t *TmuxWrapper) newPane(targetPaneID string, sizeInPercentage int, horizon
 		strconv.Itoa(sizeInPercentage) + "%",
 		"-t",
 		targetPaneID,
-		"-P",
-		"-F",
-		"#{window_id}--#{pane_id}",
 	}
+	args = appendWorkingDirectory(args, workingDirectory)
+	args = appendEnvironment(args, environment)
+	args = append(args, "-P", "-F", "#{window_id}--#{pane_id}")
 	if !horizontalSplit {
 		args[1] = "-v"
 	}
@@ -328,6 +543,7 @@ func (t *TmuxWrapper) newPane(targetPaneID string, sizeInPercentage int, horizon
 		log.Debug().Interface("output", splitOutput).Msg("invalid output from splitw sub command")
 		return nil, NewTmuxError(stdout, "", errors.New("cannot parse the windowID and pane ID from the splitw output"))
 	}
+	t.track(splitOutput[1], splitOutput[0])
 	return &TmuxCmdResponse{
 		SessionID: "",
 		WindowID:  splitOutput[0],
@@ -335,9 +551,188 @@ func (t *TmuxWrapper) newPane(targetPaneID string, sizeInPercentage int, horizon
 	}, nil
 }
 
+// workingDirectories returns the working directories of the panes of the window, the panes are created in them
+// It returns nothing if the config asks to change the directories with cd, see TmuxWrapper.changeDirectory.
+func (t *TmuxWrapper) workingDirectories(window *Window) map[string]string {
+	var workingDirectories = make(map[string]string)
+	if t.config.ChangeDirectory {
+		return workingDirectories
+	}
+	for _, command := range window.Commands {
+		if len(command.WorkingDirectory) > 0 {
+			workingDirectories[command.Name] = command.WorkingDirectory
+		}
+	}
+	return workingDirectories
+}
+
+// appendWorkingDirectory appends the -c flag of the TMUX commands that create a pane
+func appendWorkingDirectory(args []string, workingDirectory string) []string {
+	if len(workingDirectory) == 0 {
+		return args
+	}
+	return append(args, "-c", workingDirectory)
+}
+
+// paneEnvironment returns the environment of the pane, the env of the commands of the pane overrides the env of the
+// window. The environment of the session is not included as TMUX gives it to every pane of the session.
+func (t *TmuxWrapper) paneEnvironment(window *Window, paneName string) map[string]string {
+	var environment = make(map[string]string)
+	for name, value := range window.Env {
+		environment[name] = value
+	}
+	for _, command := range window.Commands {
+		if command.Name != paneName {
+			continue
+		}
+		for name, value := range command.Env {
+			environment[name] = value
+		}
+	}
+	return environment
+}
+
+// appendEnvironment appends the -e flags of the TMUX commands that create a session or a pane, the values are passed
+// as they are since TMUX does not run them through a shell
+func appendEnvironment(args []string, environment map[string]string) []string {
+	for _, name := range sortedNames(environment) {
+		args = append(args, "-e", name+"="+environment[name])
+	}
+	return args
+}
+
+// respawnPane restarts the shell of a pane that was just created with the environment, the pane keeps its working
+// directory
+func (t *TmuxWrapper) respawnPane(targetPaneID string, environment map[string]string) error {
+	// tmux respawn-pane -k -t %1 -e PORT=8080
+	var args = []string{
+		"respawn-pane",
+		"-k",
+		"-t",
+		targetPaneID,
+	}
+	args = appendEnvironment(args, environment)
+	stdout, stderr, _, err := t.executor.Execute(CommandName, args...)
+	if err != nil {
+		return NewTmuxError(stdout, stderr, err)
+	}
+	return nil
+}
+
+// setEnvironment sets the environment of a session that is already present, the panes created after it get the
+// environment
+func (t *TmuxWrapper) setEnvironment(sessionName string, environment map[string]string) error {
+	for _, name := range sortedNames(environment) {
+		// tmux set-environment -t session1 PORT 8080
+		stdout, stderr, _, err := t.executor.Execute(CommandName, "set-environment", "-t", sessionName, name,
+			environment[name])
+		if err != nil {
+			return NewTmuxError(stdout, stderr, err)
+		}
+	}
+	return nil
+}
+
+// exportEnvironment sends export to a pane that is already present, like the pane in which chaakoo is running, as its
+// shell cannot be restarted with the environment. The variables taken from the env files are not exported, so that
+// their values, like the secrets, are not kept in the shell history.
+func (t *TmuxWrapper) exportEnvironment(targetPaneID string, window *Window, paneName string) error {
+	environment := t.paneEnvironment(window, paneName)
+	var variables, skipped []string
+	for _, name := range sortedNames(environment) {
+		if isEnvFileVariable(window, paneName, name) {
+			skipped = append(skipped, name)
+			continue
+		}
+		variables = append(variables, name+"="+quoteShell(environment[name]))
+	}
+	if len(skipped) > 0 {
+		log.Warn().Msgf("the variables of the env files, %s, are not exported in pane, %s, it is already present "+
+			"and the export would be kept in the shell history", strings.Join(skipped, ", "), paneName)
+	}
+	if len(variables) == 0 {
+		return nil
+	}
+	return t.sendKeys(targetPaneID, paneName, "export "+strings.Join(variables, " "))
+}
+
+// isEnvFileVariable returns true if the value of the variable in the pane is taken from an env file, see
+// TmuxWrapper.paneEnvironment
+func isEnvFileVariable(window *Window, paneName, name string) bool {
+	for _, command := range window.Commands {
+		if command.Name != paneName {
+			continue
+		}
+		if _, ok := command.Env[name]; ok {
+			return command.envFileNames[name]
+		}
+	}
+	return window.envFileNames[name]
+}
+
+// changeDirectory sends cd to the pane, it is used for the panes that were not created in their working directories
+func (t *TmuxWrapper) changeDirectory(targetPaneID, paneName, workingDirectory string) error {
+	return t.sendKeys(targetPaneID, paneName, "cd "+quoteShell(workingDirectory))
+}
+
+var shellSafe = regexp.MustCompile(`^[A-Za-z0-9_@%+=:,./-]+$`)
+
+// quoteShell quotes the argument for the shell, if it is required, with single quotes
+func quoteShell(arg string) string {
+	if shellSafe.MatchString(arg) {
+		return arg
+	}
+	return "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+}
+
+func (t *TmuxWrapper) swapPane(sourcePaneID, targetPaneID string) error {
+	// tmux swap-pane -d -s %3 -t %1
+	var args = []string{
+		"swap-pane",
+		"-d",
+		"-s",
+		sourcePaneID,
+		"-t",
+		targetPaneID,
+	}
+	stdout, stderr, _, err := t.executor.Execute(CommandName, args...)
+	if err != nil {
+		return NewTmuxError(stdout, stderr, err)
+	}
+	return nil
+}
+
+// Attach attaches the terminal to the session, if chaakoo is running inside TMUX then the current client is switched
+// to the session instead so that the TMUX sessions are not nested
+func (t *TmuxWrapper) Attach() error {
+	sessionName := t.config.SessionName
+	if len(os.Getenv("TMUX")) > 0 {
+		// tmux switch-client -t session2
+		var args = []string{
+			"switch-client",
+			"-t",
+			sessionName,
+		}
+		stdout, stderr, _, err := t.executor.Execute(CommandName, args...)
+		if err != nil {
+			return NewTmuxError(stdout, stderr, fmt.Errorf("cannot switch the client to session, %s: %w", sessionName, err))
+		}
+		return nil
+	}
+	// tmux attach-session -t session2
+	var args = []string{
+		"attach-session",
+		"-t",
+		sessionName,
+	}
+	if err := t.executor.ExecuteInTerminal(CommandName, args...); err != nil {
+		return fmt.Errorf("cannot attach the session, %s: %w", sessionName, err)
+	}
+	return nil
+}
+
 func (t TmuxWrapper) killSession(sessionName string) {
 	// tmux kill-session -t session2
-	log.Debug().Msgf("error while creating a new session, killing the session(%s) if it's created", sessionName)
 	var args = []string{
 		"kill-session",
 		"-t",
@@ -351,6 +746,50 @@ func (t TmuxWrapper) killSession(sessionName string) {
 	}
 }
 
+// track keeps the created window or pane for the rollback, a pane is not kept if its window is already kept
+func (t *TmuxWrapper) track(id, windowID string) {
+	for _, created := range t.created {
+		if created == windowID {
+			return
+		}
+	}
+	t.created = append(t.created, id)
+}
+
+// discard kills the windows and panes created in a session that was already present, after an error, unless the config
+// asks to keep them
+func (t *TmuxWrapper) discard() {
+	if t.config.KeepOnError {
+		log.Info().Msgf("keeping the windows and panes created in session, %s, for inspection", t.config.SessionName)
+		return
+	}
+	t.rollback()
+}
+
+// rollback kills the windows and panes created in a session that was already present
+func (t *TmuxWrapper) rollback() {
+	for i := len(t.created) - 1; i >= 0; i-- {
+		target := t.created[i]
+		// tmux kill-window -t @3 or tmux kill-pane -t %12
+		var args = []string{
+			"kill-pane",
+			"-t",
+			target,
+		}
+		if strings.HasPrefix(target, "@") {
+			args[0] = "kill-window"
+		}
+		log.Debug().Msgf("error while changing the session, killing %s", target)
+		stdout, stderr, _, err := t.executor.Execute(CommandName, args...)
+		if err != nil {
+			log.Error().Err(err).Str("stdout", stdout).
+				Str("stderr", stderr).
+				Str("target", target).Msg("unable to kill the window or pane")
+		}
+	}
+	t.created = nil
+}
+
 func (t TmuxWrapper) hasSession(sessionName string) (bool, error) {
 	// tmux ls -F #{session_name}
 	var args = []string{
@@ -381,22 +820,143 @@ func (t TmuxWrapper) hasSession(sessionName string) (bool, error) {
 	return false, nil
 }
 
-func (t TmuxWrapper) sendKeys(targetPaneID, paneName string, actions []string) error {
-	// tmux send-keys -t %23 commands... C-m
+// sendKeys types the text in the pane, as it is, and then presses the Enter key
+// TMUX does not look up the key names, like C-c, in the text. An empty text only presses the Enter key.
+func (t TmuxWrapper) sendKeys(targetPaneID, paneName, text string) error {
+	if len(text) > 0 {
+		// tmux send-keys -t %23 -l -- "commands..."
+		var args = []string{
+			"send-keys",
+			"-t",
+			targetPaneID,
+			"-l",
+			"--",
+			text,
+		}
+		stdout, stderr, _, err := t.executor.Execute(CommandName, args...)
+		if err != nil {
+			log.Error().Err(err).Str("stdout", stdout).
+				Str("stderr", stderr).
+				Str("pane", paneName).Msg("error while send-keys")
+			return NewTmuxError(stdout, stderr, fmt.Errorf("error while send-keys for pane, %s, : %w", paneName, err))
+		}
+	}
+	if err := t.sendKey(targetPaneID, "Enter"); err != nil {
+		return fmt.Errorf("error while send-keys for pane, %s, : %w", paneName, err)
+	}
+	return nil
+}
+
+// markPane keeps the pane name in the pane so that it can be found again while updating the session
+func (t *TmuxWrapper) markPane(paneID, paneName string) error {
+	// tmux set-option -p -t %23 @chaakoo-pane vim
+	var args = []string{
+		"set-option",
+		"-p",
+		"-t",
+		paneID,
+		PaneOption,
+		paneName,
+	}
+	stdout, stderr, _, err := t.executor.Execute(CommandName, args...)
+	if err != nil {
+		return NewTmuxError(stdout, stderr, fmt.Errorf("cannot set the name of pane, %s: %w", paneName, err))
+	}
+	return nil
+}
+
+// markPaneOrWarn marks the pane like markPane but a failure is only a warning, the pane options need TMUX 3.1 or later
+// and the pane names are only used by --update, freeze and down
+func (t *TmuxWrapper) markPaneOrWarn(paneID, paneName string) {
+	if err := t.markPane(paneID, paneName); err != nil {
+		log.Warn().Err(err).Msgf("cannot keep the name of pane, %s, it needs TMUX 3.1 or later", paneName)
+	}
+}
+
+// listWindows returns the windows of the session
+func (t *TmuxWrapper) listWindows(sessionName string) ([]*tmuxWindow, error) {
+	// tmux list-windows -t session2 -F "#{window_id}|:|#{window_name}|:|#{window_layout}"
+	var args = []string{
+		"list-windows",
+		"-t",
+		sessionName,
+		"-F",
+		strings.Join([]string{"#{window_id}", "#{window_name}", "#{window_layout}"}, fieldSeparator),
+	}
+	stdout, stderr, _, err := t.executor.Execute(CommandName, args...)
+	if err != nil {
+		return nil, NewTmuxError(stdout, stderr, err)
+	}
+	var windows []*tmuxWindow
+	for _, line := range strings.Split(strings.TrimRight(stdout, "\n"), "\n") {
+		fields := strings.Split(line, fieldSeparator)
+		if len(fields) != 3 {
+			log.Debug().Str("line", line).Msg("invalid output from list-windows sub command")
+			return nil, NewTmuxError(stdout, "", errors.New("cannot parse the window from the list-windows output"))
+		}
+		windows = append(windows, &tmuxWindow{id: fields[0], name: fields[1], layout: fields[2]})
+	}
+	return windows, nil
+}
+
+// listPanes returns the panes of all the windows of the session in the same order as TMUX keeps them
+func (t *TmuxWrapper) listPanes(sessionName string) ([]*tmuxPane, error) {
+	// tmux list-panes -s -t session2 -F "#{window_id}|:|#{pane_id}|:|#{pane_width}|:|..."
+	var args = []string{
+		"list-panes",
+		"-s",
+		"-t",
+		sessionName,
+		"-F",
+		strings.Join([]string{"#{window_id}", "#{pane_id}", "#{pane_width}", "#{pane_height}", "#{" + PaneOption + "}",
+			"#{pane_current_path}", "#{pane_current_command}", "#{pane_dead}", "#{pane_title}"}, fieldSeparator),
+	}
+	stdout, stderr, _, err := t.executor.Execute(CommandName, args...)
+	if err != nil {
+		return nil, NewTmuxError(stdout, stderr, err)
+	}
+	var panes []*tmuxPane
+	for _, line := range strings.Split(strings.TrimRight(stdout, "\n"), "\n") {
+		// the title is the last field so that it can contain the separator
+		fields := strings.SplitN(line, fieldSeparator, 9)
+		if len(fields) != 9 {
+			log.Debug().Str("line", line).Msg("invalid output from list-panes sub command")
+			return nil, NewTmuxError(stdout, "", errors.New("cannot parse the pane from the list-panes output"))
+		}
+		width, widthErr := strconv.Atoi(fields[2])
+		height, heightErr := strconv.Atoi(fields[3])
+		if widthErr != nil || heightErr != nil {
+			log.Debug().Str("line", line).Msg("invalid pane size in the output of list-panes sub command")
+			return nil, NewTmuxError(stdout, "", errors.New("cannot parse the pane size from the list-panes output"))
+		}
+		panes = append(panes, &tmuxPane{
+			windowID:    fields[0],
+			id:          fields[1],
+			width:       width,
+			height:      height,
+			name:        fields[4],
+			currentPath: fields[5],
+			command:     fields[6],
+			dead:        fields[7] == "1",
+			title:       fields[8],
+		})
+	}
+	return panes, nil
+}
+
+// sendKey sends the keys, like C-c or Escape, to the pane without the Enter key
+func (t *TmuxWrapper) sendKey(targetPaneID string, keys ...string) error {
+	// tmux send-keys -t %23 C-c
 	var args = []string{
 		"send-keys",
 		"-t",
 		targetPaneID,
 	}
-	args = append(args, fmt.Sprintf("%s", strings.Join(actions, " ")))
-	// append the C-m for Enter key
-	args = append(args, "C-m")
+	args = append(args, keys...)
 	stdout, stderr, _, err := t.executor.Execute(CommandName, args...)
 	if err != nil {
-		log.Error().Err(err).Str("stdout", stdout).
-			Str("stderr", stderr).
-			Str("pane", paneName).Msg("error while send-keys")
-		return NewTmuxError(stdout, stderr, fmt.Errorf("error while send-keys for pane, %s, : %w", paneName, err))
+		return NewTmuxError(stdout, stderr, fmt.Errorf("error while sending keys, %s, to pane, %s: %w",
+			strings.Join(keys, " "), targetPaneID, err))
 	}
 	return nil
 }
@@ -404,6 +964,7 @@ func (t TmuxWrapper) sendKeys(targetPaneID, paneName string, actions []string) e
 // ICommandExecutor is implemented by command executor
 type ICommandExecutor interface {
 	Execute(name string, args ...string) (string, string, int, error)
+	ExecuteInTerminal(name string, args ...string) error
 }
 
 // CommandExecutor implements the command execution on a shell
@@ -433,6 +994,17 @@ func (c *CommandExecutor) Execute(name string, args ...string) (string, string,
 	return stdout.String(), stderr.String(), exitCode, err
 }
 
+// ExecuteInTerminal executes the provided command with the standard input, output and error of chaakoo so that the
+// command can use the terminal
+func (c *CommandExecutor) ExecuteInTerminal(name string, args ...string) error {
+	command := exec.Command(name, args...)
+	log.Debug().Str("command", command.String()).Msgf("executing in the terminal...")
+	command.Stdin = os.Stdin
+	command.Stdout = os.Stdout
+	command.Stderr = os.Stderr
+	return command.Run()
+}
+
 // NOOPExecutor is used for dry runs
 type NOOPExecutor struct {
 }
@@ -449,3 +1021,10 @@ func (c *NOOPExecutor) Execute(name string, args ...string) (string, string, int
 	log.Debug().Str("command", command.String()).Msgf("executing...")
 	return "@5--%15", "", 0, nil
 }
+
+// ExecuteInTerminal just logs the command
+func (c *NOOPExecutor) ExecuteInTerminal(name string, args ...string) error {
+	command := exec.Command(name, args...)
+	log.Debug().Str("command", command.String()).Msgf("executing in the terminal...")
+	return nil
+}
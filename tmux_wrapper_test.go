@@ -2,28 +2,55 @@ package chaakoo
 
 import (
 	"errors"
+	"fmt"
 	"github.com/golang/mock/gomock"
 	"github.com/pallavJha/chaakoo/mocks"
 	"github.com/spf13/viper"
 	"github.com/stretchr/testify/require"
+	"os"
 	"strings"
 	"testing"
+	"time"
 )
 
 type TmuxWrapperTestCase struct {
-	ID          int
-	Error       string
-	Ignore      bool
-	Dimension   *Dimension
-	SessionName string
-	Windows     []*Window
-	Commands    []*struct {
-		Name     string
-		Args     string
-		Stdout   string
-		Stderr   string
-		Err      string
-		ExitCode int
+	ID              int
+	Error           string
+	Ignore          bool
+	Update          bool
+	ExitOnError     bool
+	KeepOnError     bool
+	Replace         bool
+	StopTimeout     time.Duration
+	Attach          bool
+	Here            bool
+	CurrentWindow   bool
+	Split           bool
+	Down            bool
+	Tmux            string // value of the TMUX environment variable
+	TmuxPane        string // value of the TMUX_PANE environment variable
+	Env             map[string]string
+	SessionEnv      map[string]string // env of the config
+	SessionEnvFile  string            // env_file of the config
+	Vars            map[string]string // vars of the config
+	Set             map[string]string // values of the --set flags
+	Directory       string
+	ChangeDirectory bool
+	Scripts         []string // scripts that are expected to be written
+	Dimension       *Dimension
+	SessionName     string
+	Hooks           *Hooks
+	Windows         []*Window
+	Commands        []*struct {
+		Name       string
+		Args       string
+		Stdout     string
+		Stderr     string
+		Err        string
+		ExitCode   int
+		InTerminal bool
+		Label      string   // label of the call, that can be used in the After of the next calls
+		After      []string // labels of the calls that must happen before this call
 	}
 }
 
@@ -42,46 +69,137 @@ func (c TmuxWrapperTestSuite) testTmuxWrapperApply(t *testing.T) {
 		}
 		t.Log("testing, id", testCase.ID)
 		config := &Config{
-			SessionName: testCase.SessionName,
-			Windows:     testCase.Windows,
+			SessionName:     testCase.SessionName,
+			Windows:         testCase.Windows,
+			Update:          testCase.Update,
+			ExitOnError:     testCase.ExitOnError,
+			KeepOnError:     testCase.KeepOnError,
+			Replace:         testCase.Replace,
+			StopTimeout:     testCase.StopTimeout,
+			Attach:          testCase.Attach,
+			Here:            testCase.Here,
+			CurrentWindow:   testCase.CurrentWindow,
+			Directory:       testCase.Directory,
+			ChangeDirectory: testCase.ChangeDirectory,
+			Env:             testCase.SessionEnv,
+			EnvFile:         testCase.SessionEnvFile,
+			Vars:            testCase.Vars,
+			Hooks:           testCase.Hooks,
+		}
+		restoreEnv := setEnv(testCase.Env)
+		err := prepareConfig(config, testCase.Set)
+		restoreEnv()
+		if err != nil {
+			require.EqualError(t, err, testCase.Error)
+			continue
 		}
-		err := config.Validate()
-		require.NoError(t, err)
-		err = config.Parse()
-		require.NoError(t, err)
 		wrapper := NewTmuxWrapper(config, testCase.Dimension)
 
 		mockCmdExecutor := mocks.NewMockICommandExecutor(ctrl)
 		wrapper.executor = mockCmdExecutor
+		var scripts []string
+		wrapper.scriptWriter = func(script string) (string, error) {
+			scripts = append(scripts, script)
+			return fmt.Sprintf("/tmp/chaakoo-%d.sh", len(scripts)), nil
+		}
+		var calls = make(map[string]*gomock.Call)
 		for _, command := range testCase.Commands {
 			command.Args = strings.TrimSpace(command.Args)
-			arguments := strings.Split(command.Args, " ")
+			arguments := splitArgs(command.Args)
 			var errorToReturn error
 			if len(command.Err) > 0 {
 				errorToReturn = errors.New(command.Err)
 			}
-			adjustSendKeysArgs(arguments)
-			mockCmdExecutor.EXPECT().Execute(command.Name, adjustSendKeysArgs(arguments)).Return(
-				command.Stdout, command.Stderr, command.ExitCode, errorToReturn,
-			)
+			var call *gomock.Call
+			if command.InTerminal {
+				call = mockCmdExecutor.EXPECT().ExecuteInTerminal(command.Name, arguments).Return(errorToReturn)
+			} else {
+				call = mockCmdExecutor.EXPECT().Execute(command.Name, arguments).Return(
+					command.Stdout, command.Stderr, command.ExitCode, errorToReturn,
+				)
+			}
+			for _, label := range command.After {
+				require.Contains(t, calls, label, "unknown label in the after of %s", command.Args)
+				call.After(calls[label])
+			}
+			if len(command.Label) > 0 {
+				calls[command.Label] = call
+			}
 		}
 
-		err = wrapper.Apply()
+		restoreTmuxEnv := setEnv(map[string]string{"TMUX": testCase.Tmux, "TMUX_PANE": testCase.TmuxPane})
+		if testCase.Split {
+			err = wrapper.Split(config.Windows[0])
+		} else if testCase.Down {
+			err = wrapper.Down()
+		} else {
+			err = wrapper.Apply()
+		}
+		if err == nil && testCase.Attach {
+			err = wrapper.Attach()
+		}
+		restoreTmuxEnv()
 		if len(testCase.Error) > 0 {
 			require.Error(t, err)
 			require.EqualError(t, err, testCase.Error)
 		} else {
 			require.NoError(t, err)
 		}
+		require.Equal(t, testCase.Scripts, scripts)
+	}
+}
+
+// prepareConfig prepares the config in the same order as the root command
+func prepareConfig(config *Config, values map[string]string) error {
+	if err := config.Interpolate(values); err != nil {
+		return err
+	}
+	if err := config.Validate(); err != nil {
+		return err
+	}
+	if err := config.Parse(); err != nil {
+		return err
+	}
+	if err := config.ResolveWorkingDirectories(); err != nil {
+		return err
+	}
+	return config.LoadEnvFiles()
+}
+
+// setEnv sets the environment variables and returns a func to restore them, an empty value unsets the variable
+func setEnv(env map[string]string) func() {
+	var currentValues = make(map[string]*string)
+	for name, value := range env {
+		currentValues[name] = nil
+		if currentValue, ok := os.LookupEnv(name); ok {
+			currentValues[name] = &currentValue
+		}
+		if len(value) > 0 {
+			os.Setenv(name, value)
+		} else {
+			os.Unsetenv(name)
+		}
+	}
+	return func() {
+		for name, currentValue := range currentValues {
+			if currentValue == nil {
+				os.Unsetenv(name)
+			} else {
+				os.Setenv(name, *currentValue)
+			}
+		}
 	}
 }
 
-func adjustSendKeysArgs(args []string) []string {
-	if args[0] != "send-keys" {
-		return args
+// splitArgs splits the args by spaces, the text of send-keys -l is kept as it is
+func splitArgs(args string) []string {
+	arguments := strings.Split(args, " ")
+	if len(arguments) > 5 && arguments[0] == "send-keys" && arguments[3] == "-l" {
+		return strings.SplitN(args, " ", 6)
+	}
+	// the script of sh -c, like a hook
+	if len(arguments) > 1 && arguments[0] == "-c" {
+		return strings.SplitN(args, " ", 2)
 	}
-	var newArgs = make([]string, 3)
-	copy(newArgs, args[0:3])
-	newArgs = append(newArgs, strings.Join(args[3:len(args)-1], " "), "C-m")
-	return newArgs
+	return arguments
 }
@@ -0,0 +1,192 @@
+package chaakoo
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+)
+
+// update reconciles the running session with the config:
+// 	- the windows that are not present in the session are created
+// 	- the panes that are not present in a window are created and then the layout of the grid is applied on it
+// 	- the windows and panes that are already present are left as they are, their commands are not executed again
+// The windows that cannot be reconciled, like the windows with panes that are not present in the grid, are not
+// changed and they are returned in the error after the rest of the session is updated.
+// If an error occurs then the windows and panes created by the update are killed, unless the config asks to keep them.
+func (t *TmuxWrapper) update() error {
+	// the panes that are already present keep their environment, the panes created by the update get the new one
+	if err := t.setEnvironment(t.config.SessionName, t.config.Env); err != nil {
+		return fmt.Errorf("cannot set the env of session, %s: %w", t.config.SessionName, err)
+	}
+	issues, err := t.updateWindows()
+	if err != nil {
+		t.discard()
+		return err
+	}
+	if len(issues) > 0 {
+		return fmt.Errorf("cannot reconcile session, %s: %s", t.config.SessionName, strings.Join(issues, "; "))
+	}
+	return nil
+}
+
+// updateWindows returns the issues of the windows that cannot be reconciled
+func (t *TmuxWrapper) updateWindows() ([]string, error) {
+	sessionName := t.config.SessionName
+	tmuxWindows, err := t.listWindows(sessionName)
+	if err != nil {
+		return nil, fmt.Errorf("cannot list the windows of session, %s: %w", sessionName, err)
+	}
+	tmuxPanes, err := t.listPanes(sessionName)
+	if err != nil {
+		return nil, fmt.Errorf("cannot list the panes of session, %s: %w", sessionName, err)
+	}
+	// new windows get the size of the windows that are already present in the session
+	if currentLayout, err := ParseLayout(tmuxWindows[0].layout); err == nil {
+		t.dimension = NewDimension(currentLayout.Width, currentLayout.Height)
+	}
+	var windowsByName = make(map[string]*tmuxWindow)
+	for _, tmuxWindow := range tmuxWindows {
+		windowsByName[tmuxWindow.name] = tmuxWindow
+	}
+	var panesByWindow = make(map[string][]*tmuxPane)
+	for _, pane := range tmuxPanes {
+		panesByWindow[pane.windowID] = append(panesByWindow[pane.windowID], pane)
+	}
+
+	var issues []string
+	for _, window := range t.config.Windows {
+		tmuxWindow, ok := windowsByName[window.Name]
+		if !ok {
+			log.Info().Msgf("creating window, %s", window.Name)
+			res, err := t.newWindow(sessionName, window.Name, t.workingDirectories(window)[window.FirstPane.Name],
+				t.paneEnvironment(window, window.FirstPane.Name))
+			if err != nil {
+				return nil, fmt.Errorf("cannot create the window, %s: %w", window.Name, err)
+			}
+			paneNames, err := t.preparePanes(window, res)
+			if err != nil {
+				return nil, err
+			}
+			if err = t.handleRunCommands(window, paneNames); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		delete(windowsByName, window.Name)
+		issue, err := t.updateWindow(window, tmuxWindow, panesByWindow[tmuxWindow.id])
+		if err != nil {
+			return nil, err
+		}
+		if len(issue) > 0 {
+			log.Warn().Msg(issue)
+			issues = append(issues, issue)
+		}
+	}
+	for name := range windowsByName {
+		log.Info().Msgf("window, %s, is not present in the config, leaving it as it is", name)
+	}
+	if err = t.runCommandGraph(); err != nil {
+		return nil, err
+	}
+	return issues, nil
+}
+
+// updateWindow creates the panes of the window that are not present in the running window
+// It returns an issue if the window cannot be reconciled.
+func (t *TmuxWrapper) updateWindow(window *Window, tmuxWindow *tmuxWindow, panes []*tmuxPane) (string, error) {
+	currentLayout, err := ParseLayout(tmuxWindow.layout)
+	if err != nil {
+		return "", fmt.Errorf("cannot parse the layout of window, %s: %w", window.Name, err)
+	}
+	layout, err := PrepareLayout(window.FirstPane.AsGrid(), NewDimension(currentLayout.Width, currentLayout.Height))
+	if err != nil {
+		return "", fmt.Errorf("cannot prepare the layout for window, %s: %w", window.Name, err)
+	}
+	layoutPaneNames := layout.PaneNames()
+	var configPaneNames = make(map[string]bool)
+	for _, paneName := range layoutPaneNames {
+		configPaneNames[paneName] = true
+	}
+
+	var paneNames = make(map[string]string)
+	var paneOrder []string
+	var unnamedPanes int
+	for _, pane := range panes {
+		paneOrder = append(paneOrder, pane.id)
+		if len(pane.name) == 0 {
+			unnamedPanes++
+		} else if !configPaneNames[pane.name] {
+			return fmt.Sprintf("window, %s, has pane, %s, which is not present in the grid", window.Name, pane.name), nil
+		} else {
+			paneNames[pane.name] = pane.id
+		}
+	}
+	if unnamedPanes > 0 {
+		if unnamedPanes == len(panes) && len(panes) == len(layoutPaneNames) {
+			log.Info().Msgf("panes of window, %s, were not named by chaakoo, leaving them as they are", window.Name)
+			return "", nil
+		}
+		return fmt.Sprintf("window, %s, has %d panes that were not created by chaakoo", window.Name, unnamedPanes), nil
+	}
+
+	var newPaneNames = make(map[string]string)
+	for _, paneName := range layoutPaneNames {
+		if _, ok := paneNames[paneName]; ok {
+			continue
+		}
+		// the largest pane is split so that TMUX has space for the new pane
+		largestPane := panes[0]
+		for _, pane := range panes {
+			if pane.width*pane.height > largestPane.width*largestPane.height {
+				largestPane = pane
+			}
+		}
+		horizontalSplit := largestPane.width >= 2*largestPane.height
+		res, err := t.newPane(largestPane.id, 50, horizontalSplit, t.workingDirectories(window)[paneName],
+			t.paneEnvironment(window, paneName))
+		if err != nil {
+			return "", fmt.Errorf("cannot create pane, %s, in window, %s: %w", paneName, window.Name, err)
+		}
+		newPane := &tmuxPane{windowID: tmuxWindow.id, id: res.PaneID, name: paneName,
+			width: largestPane.width, height: largestPane.height}
+		if horizontalSplit {
+			largestPane.width, newPane.width = largestPane.width/2, largestPane.width/2
+		} else {
+			largestPane.height, newPane.height = largestPane.height/2, largestPane.height/2
+		}
+		panes = append(panes, newPane)
+		paneOrder = insertAfter(paneOrder, largestPane.id, res.PaneID)
+		paneNames[paneName] = res.PaneID
+		newPaneNames[paneName] = res.PaneID
+		if err = t.markPane(res.PaneID, paneName); err != nil {
+			return "", err
+		}
+	}
+	if len(newPaneNames) == 0 {
+		log.Debug().Msgf("window, %s, is already present with all the panes", window.Name)
+		return "", nil
+	}
+
+	// TMUX assigns the panes to the layout in order, so the panes are swapped until they are in the layout order
+	for i, paneName := range layoutPaneNames {
+		paneID := paneNames[paneName]
+		if paneOrder[i] == paneID {
+			continue
+		}
+		for j := i + 1; j < len(paneOrder); j++ {
+			if paneOrder[j] == paneID {
+				if err = t.swapPane(paneID, paneOrder[i]); err != nil {
+					return "", fmt.Errorf("cannot arrange the panes of window, %s: %w", window.Name, err)
+				}
+				paneOrder[i], paneOrder[j] = paneOrder[j], paneOrder[i]
+				break
+			}
+		}
+	}
+	if err = t.selectLayout(tmuxWindow.id, layout, paneNames, paneOrder); err != nil {
+		return "", fmt.Errorf("cannot apply the layout for window, %s: %w", window.Name, err)
+	}
+	log.Info().Msgf("created %d panes in window, %s", len(newPaneNames), window.Name)
+	return "", t.handleRunCommands(window, newPaneNames)
+}
@@ -0,0 +1,160 @@
+package chaakoo
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// WaitCondition is a readiness condition of a command, with wait_for in the config. The commands after it are only sent
+// when the condition holds, see TmuxWrapper.waitFor. A condition has a timeout, like 30s, and one of these:
+// 	- port, a TCP address that accepts the connections, a port without a host is on localhost
+// 	- file, a file or a directory that must be present, it is resolved like the working directories
+// 	- http, a URL that answers with a status below 400
+// 	- output, a regular expression that matches the text of the pane, the text is read with capture-pane
+type WaitCondition struct {
+	Port    string `mapstructure:"port" yaml:"port,omitempty"`
+	File    string `mapstructure:"file" yaml:"file,omitempty"`
+	HTTP    string `mapstructure:"http" yaml:"http,omitempty"`
+	Output  string `mapstructure:"output" yaml:"output,omitempty"`
+	Timeout string `mapstructure:"timeout" yaml:"timeout"`
+}
+
+func (w *WaitCondition) validate() error {
+	var kinds int
+	for _, value := range []string{w.Port, w.File, w.HTTP, w.Output} {
+		if len(strings.TrimSpace(value)) > 0 {
+			kinds++
+		}
+	}
+	if kinds != 1 {
+		return errors.New("wait_for must have one of port, file, http or output")
+	}
+	if len(w.Timeout) == 0 {
+		return fmt.Errorf("wait_for, %s, needs a timeout", w)
+	}
+	if timeout, err := time.ParseDuration(w.Timeout); err != nil || timeout <= 0 {
+		return fmt.Errorf("invalid timeout, %s, for wait_for, %s", w.Timeout, w)
+	}
+	if _, err := regexp.Compile(w.Output); err != nil {
+		return fmt.Errorf("invalid output pattern for wait_for, %s: %w", w, err)
+	}
+	return nil
+}
+
+// String describes the condition for the logs and the errors
+func (w *WaitCondition) String() string {
+	switch {
+	case len(strings.TrimSpace(w.Port)) > 0:
+		return "port " + w.address()
+	case len(strings.TrimSpace(w.File)) > 0:
+		return "file " + w.File
+	case len(strings.TrimSpace(w.HTTP)) > 0:
+		return "http " + w.HTTP
+	default:
+		return "output " + w.Output
+	}
+}
+
+// address returns the TCP address of the port, localhost is used if the port does not have a host
+func (w *WaitCondition) address() string {
+	port := strings.TrimSpace(w.Port)
+	if strings.Contains(port, ":") {
+		return port
+	}
+	return "localhost:" + port
+}
+
+// waitFor waits for the conditions of the command one after the other. If a condition does not hold before its timeout
+// then it returns an error with ExitOnError, otherwise it logs a warning and the next commands are sent. The
+// conditions are skipped in the dry runs.
+func (t *TmuxWrapper) waitFor(paneID string, windowName string, command *Command) error {
+	for _, condition := range command.WaitFor {
+		if t.config.DryRun {
+			log.Debug().Msgf("skipping the wait for %s of pane, %s, in the dry run", condition, command.Name)
+			continue
+		}
+		if err := t.wait(paneID, condition); err != nil {
+			err = fmt.Errorf("pane, %s, in window, %s, is not ready: %w", command.Name, windowName, err)
+			if t.config.ExitOnError {
+				return err
+			}
+			log.Warn().Err(err).Msg("sending the next commands")
+		}
+	}
+	return nil
+}
+
+func (t *TmuxWrapper) wait(paneID string, condition *WaitCondition) error {
+	timeout, err := time.ParseDuration(condition.Timeout)
+	if err != nil {
+		return fmt.Errorf("invalid timeout, %s: %w", condition.Timeout, err)
+	}
+	log.Info().Msgf("waiting for %s, up to %s", condition, timeout)
+	deadline := time.Now().Add(timeout)
+	for {
+		ready, err := t.ready(paneID, condition)
+		if err != nil {
+			return err
+		}
+		if ready {
+			log.Debug().Msgf("%s is ready", condition)
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("%s is not ready after %s", condition, timeout)
+		}
+		time.Sleep(PollInterval)
+	}
+}
+
+// ready checks the condition once
+func (t *TmuxWrapper) ready(paneID string, condition *WaitCondition) (bool, error) {
+	switch {
+	case len(strings.TrimSpace(condition.Port)) > 0:
+		connection, err := net.DialTimeout("tcp", condition.address(), time.Second)
+		if err != nil {
+			return false, nil
+		}
+		connection.Close()
+		return true, nil
+	case len(strings.TrimSpace(condition.File)) > 0:
+		path, err := resolvePath(strings.TrimSpace(condition.File), t.config.Directory)
+		if err != nil {
+			return false, fmt.Errorf("invalid file, %s: %w", condition.File, err)
+		}
+		_, err = os.Stat(path)
+		return err == nil, nil
+	case len(strings.TrimSpace(condition.HTTP)) > 0:
+		client := http.Client{Timeout: time.Second}
+		response, err := client.Get(strings.TrimSpace(condition.HTTP))
+		if err != nil {
+			return false, nil
+		}
+		response.Body.Close()
+		return response.StatusCode < 400, nil
+	default:
+		output, err := t.capturePane(paneID)
+		if err != nil {
+			return false, err
+		}
+		return regexp.MustCompile(condition.Output).MatchString(output), nil
+	}
+}
+
+// capturePane returns the text of the pane, the wrapped lines are joined
+func (t *TmuxWrapper) capturePane(paneID string) (string, error) {
+	// tmux capture-pane -p -J -t %23
+	stdout, stderr, _, err := t.executor.Execute(CommandName, "capture-pane", "-p", "-J", "-t", paneID)
+	if err != nil {
+		return "", NewTmuxError(stdout, stderr, fmt.Errorf("cannot capture the text of pane, %s: %w", paneID, err))
+	}
+	return stdout, nil
+}